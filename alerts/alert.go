@@ -0,0 +1,122 @@
+// Package alerts decodes weather alerts, from the NWS API GeoJSON format or CAP 1.2 XML, and maps
+// the areas they affect to geodata zones
+package alerts
+
+import (
+	"errors"
+	"time"
+
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+)
+
+// Severity is the CAP severity of an alert
+type Severity string
+
+// The CAP 1.2 severities
+const (
+	SeverityExtreme  Severity = "Extreme"
+	SeveritySevere   Severity = "Severe"
+	SeverityModerate Severity = "Moderate"
+	SeverityMinor    Severity = "Minor"
+	SeverityUnknown  Severity = "Unknown"
+)
+
+// Urgency is the CAP urgency of an alert
+type Urgency string
+
+// The CAP 1.2 urgencies
+const (
+	UrgencyImmediate Urgency = "Immediate"
+	UrgencyExpected  Urgency = "Expected"
+	UrgencyFuture    Urgency = "Future"
+	UrgencyPast      Urgency = "Past"
+	UrgencyUnknown   Urgency = "Unknown"
+)
+
+// Certainty is the CAP certainty of an alert
+type Certainty string
+
+// The CAP 1.2 certainties. NWS products also use the CAP 1.0 value "Very Likely", which is
+// decoded as [CertaintyLikely]
+const (
+	CertaintyObserved Certainty = "Observed"
+	CertaintyLikely   Certainty = "Likely"
+	CertaintyPossible Certainty = "Possible"
+	CertaintyUnlikely Certainty = "Unlikely"
+	CertaintyUnknown  Certainty = "Unknown"
+)
+
+var (
+	// ErrInvalidAlert is returned when a document cannot be decoded as an alert
+	ErrInvalidAlert = errors.New("invalid alert")
+)
+
+// Alert is a weather alert, with the fields common to the NWS API and CAP 1.2. Times that were not
+// given are zero
+type Alert struct {
+	// ID is the NWS API URL of the alert, or the CAP identifier if it was decoded from CAP
+	ID          string   `json:"id"`
+	Identifier  string   `json:"identifier"`
+	Sender      string   `json:"sender"`
+	SenderName  string   `json:"senderName"`
+	Status      string   `json:"status"`
+	MessageType string   `json:"messageType"`
+	Category    string   `json:"category"`
+	References  []string `json:"references,omitempty"`
+
+	Event       string `json:"event"`
+	Headline    string `json:"headline"`
+	Description string `json:"description"`
+	Instruction string `json:"instruction"`
+
+	Severity  Severity  `json:"severity"`
+	Urgency   Urgency   `json:"urgency"`
+	Certainty Certainty `json:"certainty"`
+
+	Sent      time.Time `json:"sent"`
+	Effective time.Time `json:"effective"`
+	Onset     time.Time `json:"onset"`
+	Expires   time.Time `json:"expires"`
+	Ends      time.Time `json:"ends"`
+
+	AreaDesc string `json:"areaDesc"`
+	// AffectedZones are the NWS API IDs of the affected zones, e.g.
+	// https://api.weather.gov/zones/fire/OHZ011. Only alerts from the NWS API have them
+	AffectedZones []string `json:"affectedZones,omitempty"`
+	// UGC are the individual UGC codes of the affected areas, e.g. OHZ003
+	UGC []string `json:"ugc,omitempty"`
+	// SAME are the six digit SAME codes of the affected areas
+	SAME []string `json:"same,omitempty"`
+	// VTEC are the P-VTEC strings carried by the alert, if any
+	VTEC []string `json:"vtec,omitempty"`
+	// Polygon is the storm-based warning polygon, if any
+	Polygon *geodata.Geometry `json:"polygon,omitempty"`
+}
+
+// End returns when the hazard ends, which is Ends if it was given and Expires otherwise
+func (a *Alert) End() time.Time {
+	if !a.Ends.IsZero() {
+		return a.Ends
+	}
+
+	return a.Expires
+}
+
+// Active reports whether the alert is in effect at t
+func (a *Alert) Active(t time.Time) bool {
+	start := a.Onset
+	if start.IsZero() {
+		start = a.Effective
+	}
+
+	end := a.End()
+	return !t.Before(start) && (end.IsZero() || t.Before(end))
+}
+
+func normalizeCertainty(c string) Certainty {
+	if c == "Very Likely" {
+		return CertaintyLikely
+	}
+
+	return Certainty(c)
+}
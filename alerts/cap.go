@@ -0,0 +1,209 @@
+package alerts
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paulmach/orb"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+)
+
+// CAPNamespace is the XML namespace of CAP 1.2 documents
+const CAPNamespace = "urn:oasis:names:tc:emergency:cap:1.2"
+
+type capValue struct {
+	ValueName string `xml:"valueName"`
+	Value     string `xml:"value"`
+}
+
+type capArea struct {
+	AreaDesc string     `xml:"areaDesc"`
+	Polygons []string   `xml:"polygon"`
+	Geocodes []capValue `xml:"geocode"`
+}
+
+type capInfo struct {
+	Language    string     `xml:"language"`
+	Category    string     `xml:"category"`
+	Event       string     `xml:"event"`
+	Urgency     string     `xml:"urgency"`
+	Severity    string     `xml:"severity"`
+	Certainty   string     `xml:"certainty"`
+	Effective   string     `xml:"effective"`
+	Onset       string     `xml:"onset"`
+	Expires     string     `xml:"expires"`
+	SenderName  string     `xml:"senderName"`
+	Headline    string     `xml:"headline"`
+	Description string     `xml:"description"`
+	Instruction string     `xml:"instruction"`
+	Parameters  []capValue `xml:"parameter"`
+	Areas       []capArea  `xml:"area"`
+}
+
+type capAlert struct {
+	XMLName    xml.Name  `xml:"alert"`
+	Identifier string    `xml:"identifier"`
+	Sender     string    `xml:"sender"`
+	Sent       string    `xml:"sent"`
+	Status     string    `xml:"status"`
+	MsgType    string    `xml:"msgType"`
+	References string    `xml:"references"`
+	Info       []capInfo `xml:"info"`
+}
+
+// DecodeCAP decodes a CAP 1.2 alert document. Only the first English info block is used, or the
+// first info block if none is in English, and the areas of that block are combined
+func DecodeCAP(r io.Reader) (*Alert, error) {
+	var doc capAlert
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidAlert, err)
+	}
+
+	if doc.XMLName.Space != "" && doc.XMLName.Space != CAPNamespace {
+		return nil, fmt.Errorf("%w: unsupported namespace %s", ErrInvalidAlert, doc.XMLName.Space)
+	}
+
+	if len(doc.Info) == 0 {
+		return nil, fmt.Errorf("%w: %s has no info", ErrInvalidAlert, doc.Identifier)
+	}
+
+	info := doc.Info[0]
+	for _, i := range doc.Info {
+		if strings.HasPrefix(strings.ToLower(i.Language), "en") {
+			info = i
+			break
+		}
+	}
+
+	a := &Alert{
+		ID:          doc.Identifier,
+		Identifier:  doc.Identifier,
+		Sender:      doc.Sender,
+		SenderName:  info.SenderName,
+		Status:      doc.Status,
+		MessageType: doc.MsgType,
+		Category:    info.Category,
+		Event:       info.Event,
+		Headline:    info.Headline,
+		Description: strings.TrimSpace(info.Description),
+		Instruction: strings.TrimSpace(info.Instruction),
+		Severity:    Severity(info.Severity),
+		Urgency:     Urgency(info.Urgency),
+		Certainty:   normalizeCertainty(info.Certainty),
+		VTEC:        values(info.Parameters, "VTEC"),
+	}
+
+	// references are space separated sender,identifier,sent triples
+	for _, ref := range strings.Fields(doc.References) {
+		if parts := strings.Split(ref, ","); len(parts) == 3 {
+			a.References = append(a.References, parts[1])
+		}
+	}
+
+	for _, t := range []struct {
+		dst *time.Time
+		src string
+	}{
+		{&a.Sent, doc.Sent},
+		{&a.Effective, info.Effective},
+		{&a.Onset, info.Onset},
+		{&a.Expires, info.Expires},
+		{&a.Ends, first(values(info.Parameters, "eventEndingTime"))},
+	} {
+		if t.src == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, strings.TrimSpace(t.src))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidAlert, doc.Identifier, err)
+		}
+		*t.dst = parsed
+	}
+
+	var descs []string
+	var polygons orb.MultiPolygon
+	for _, area := range info.Areas {
+		descs = append(descs, area.AreaDesc)
+		a.UGC = append(a.UGC, values(area.Geocodes, "UGC")...)
+		a.SAME = append(a.SAME, values(area.Geocodes, "SAME")...)
+
+		for _, p := range area.Polygons {
+			ring, perr := parseCAPPolygon(p)
+			if perr != nil {
+				return nil, fmt.Errorf("%w: %s: %w", ErrInvalidAlert, doc.Identifier, perr)
+			}
+
+			polygons = append(polygons, orb.Polygon{ring})
+		}
+	}
+	a.AreaDesc = strings.Join(descs, "; ")
+
+	switch len(polygons) {
+	case 0:
+	case 1:
+		a.Polygon = geodata.FromOrbGeometry(polygons[0])
+	default:
+		a.Polygon = geodata.FromOrbGeometry(polygons)
+	}
+
+	return a, nil
+}
+
+// parseCAPPolygon parses a CAP polygon, which is a closed ring of space separated "lat,lon" pairs
+func parseCAPPolygon(s string) (orb.Ring, error) {
+	pairs := strings.Fields(s)
+	if len(pairs) < 4 {
+		return nil, fmt.Errorf("polygon has %d points", len(pairs))
+	}
+
+	ring := make(orb.Ring, 0, len(pairs))
+	for _, pair := range pairs {
+		latStr, lonStr, ok := strings.Cut(pair, ",")
+		if !ok {
+			return nil, fmt.Errorf("bad point %q", pair)
+		}
+
+		lat, err := strconv.ParseFloat(latStr, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		lon, err := strconv.ParseFloat(lonStr, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		ring = append(ring, orb.Point{lon, lat})
+	}
+
+	if !ring.Closed() {
+		ring = append(ring, ring[0])
+	}
+
+	return ring, nil
+}
+
+// values returns every value with the given name
+func values(vs []capValue, name string) []string {
+	var out []string
+	for _, v := range vs {
+		if v.ValueName == name {
+			out = append(out, strings.TrimSpace(v.Value))
+		}
+	}
+
+	return out
+}
+
+func first(vs []string) string {
+	if len(vs) == 0 {
+		return ""
+	}
+
+	return vs[0]
+}
@@ -0,0 +1,116 @@
+package alerts
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/paulmach/orb"
+)
+
+func decodeCAPFile(t *testing.T, name string) *Alert {
+	t.Helper()
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	a, err := DecodeCAP(f)
+	if err != nil {
+		t.Fatalf("DecodeCAP(%s) = %v", name, err)
+	}
+
+	return a
+}
+
+func TestDecodeCAP(t *testing.T) {
+	a := decodeCAPFile(t, "testdata/cap-red-flag.xml")
+
+	// the Spanish info block comes first
+	if a.Event != "Red Flag Warning" || a.SenderName != "NWS Wilmington OH" {
+		t.Errorf("Event, SenderName = %q, %q, want the English info block", a.Event, a.SenderName)
+	}
+
+	if a.ID != a.Identifier || !strings.HasPrefix(a.ID, "urn:oid:") {
+		t.Errorf("ID, Identifier = %q, %q, want the CAP identifier", a.ID, a.Identifier)
+	}
+
+	if !strings.HasPrefix(a.Description, "* AFFECTED AREA") || strings.HasSuffix(a.Description, "\n") {
+		t.Errorf("Description = %q, want it trimmed", a.Description)
+	}
+
+	edt := time.FixedZone("EDT", -4*60*60)
+	if want := time.Date(2025, time.October, 18, 20, 0, 0, 0, edt); !a.Ends.Equal(want) {
+		t.Errorf("Ends = %s, want eventEndingTime %s", a.Ends, want)
+	}
+
+	if want := []string{"OHZ055", "OHZ056"}; !slices.Equal(a.UGC, want) {
+		t.Errorf("UGC = %v, want %v", a.UGC, want)
+	}
+
+	if want := []string{"039049", "039097"}; !slices.Equal(a.SAME, want) {
+		t.Errorf("SAME = %v, want %v", a.SAME, want)
+	}
+
+	if want := []string{"/O.NEW.KILN.FW.W.0003.251018T1600Z-251019T0000Z/"}; !slices.Equal(a.VTEC, want) {
+		t.Errorf("VTEC = %v, want %v", a.VTEC, want)
+	}
+
+	if a.AffectedZones != nil || a.Polygon != nil {
+		t.Errorf("AffectedZones, Polygon = %v, %v, want neither", a.AffectedZones, a.Polygon)
+	}
+}
+
+func TestDecodeCAPAreas(t *testing.T) {
+	a := decodeCAPFile(t, "testdata/cap-small-craft.xml")
+
+	if a.Certainty != CertaintyLikely {
+		t.Errorf("Certainty = %q, want %q", a.Certainty, CertaintyLikely)
+	}
+
+	if len(a.References) != 1 || !strings.HasSuffix(a.References[0], ".001.1") {
+		t.Errorf("References = %v, want the identifier of the original alert", a.References)
+	}
+
+	if want := "Maumee Bay to Reno Beach OH; Reno Beach to The Islands OH"; a.AreaDesc != want {
+		t.Errorf("AreaDesc = %q, want %q", a.AreaDesc, want)
+	}
+
+	if want := []string{"LEZ142", "LEZ143"}; !slices.Equal(a.UGC, want) {
+		t.Errorf("UGC = %v, want %v", a.UGC, want)
+	}
+}
+
+func TestDecodeCAPPolygon(t *testing.T) {
+	a := decodeCAPFile(t, "testdata/cap-tornado.xml")
+
+	p, ok := a.Polygon.AsOrbGeometry().(orb.Polygon)
+	if !ok {
+		t.Fatalf("Polygon = %v, want a polygon", a.Polygon.AsOrbGeometry())
+	}
+
+	// CAP points are lat,lon and the ring is closed when it is decoded
+	ring := p[0]
+	if len(ring) != 5 || ring[0] != (orb.Point{-82.62, 40.73}) || !ring.Closed() {
+		t.Errorf("ring = %v, want the closed ring in lon,lat order", ring)
+	}
+}
+
+func TestDecodeCAPErrors(t *testing.T) {
+	for _, doc := range []string{
+		`<alert xmlns="urn:oasis:names:tc:emergency:cap:1.1"><identifier>x</identifier><info/></alert>`,
+		`<alert xmlns="urn:oasis:names:tc:emergency:cap:1.2"><identifier>x</identifier></alert>`,
+		`<alert xmlns="urn:oasis:names:tc:emergency:cap:1.2"><info><onset>soon</onset></info></alert>`,
+		`<alert xmlns="urn:oasis:names:tc:emergency:cap:1.2"><info><area><polygon>40,-82 41,-82</polygon></area></info></alert>`,
+		`<alert>`,
+	} {
+		if _, err := DecodeCAP(strings.NewReader(doc)); !errors.Is(err, ErrInvalidAlert) {
+			t.Errorf("DecodeCAP(%s) = %v, want %v", doc, err, ErrInvalidAlert)
+		}
+	}
+}
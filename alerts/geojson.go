@@ -0,0 +1,155 @@
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/paulmach/orb/geojson"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+)
+
+// nwsProperties are the properties of an NWS API alert feature
+type nwsProperties struct {
+	ID            string     `json:"id"`
+	AreaDesc      string     `json:"areaDesc"`
+	AffectedZones []string   `json:"affectedZones"`
+	Sent          *time.Time `json:"sent"`
+	Effective     *time.Time `json:"effective"`
+	Onset         *time.Time `json:"onset"`
+	Expires       *time.Time `json:"expires"`
+	Ends          *time.Time `json:"ends"`
+	Status        string     `json:"status"`
+	MessageType   string     `json:"messageType"`
+	Category      string     `json:"category"`
+	Severity      string     `json:"severity"`
+	Certainty     string     `json:"certainty"`
+	Urgency       string     `json:"urgency"`
+	Event         string     `json:"event"`
+	Sender        string     `json:"sender"`
+	SenderName    string     `json:"senderName"`
+	Headline      string     `json:"headline"`
+	Description   string     `json:"description"`
+	Instruction   string     `json:"instruction"`
+	Geocode       struct {
+		SAME []string `json:"SAME"`
+		UGC  []string `json:"UGC"`
+	} `json:"geocode"`
+	References []struct {
+		Identifier string `json:"identifier"`
+	} `json:"references"`
+	Parameters map[string][]string `json:"parameters"`
+}
+
+// DecodeGeoJSON decodes an alert from the NWS API, either a single Feature (as returned by
+// /alerts/{id}) or a FeatureCollection (as returned by /alerts/active)
+func DecodeGeoJSON(r io.Reader) ([]*Alert, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var head struct {
+		Type string `json:"type"`
+	}
+	if err = json.Unmarshal(data, &head); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidAlert, err)
+	}
+
+	switch head.Type {
+	case "Feature":
+		f, err := geojson.UnmarshalFeature(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidAlert, err)
+		}
+
+		a, err := alertFromFeature(f)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*Alert{a}, nil
+	case "FeatureCollection":
+		fc, err := geojson.UnmarshalFeatureCollection(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidAlert, err)
+		}
+
+		alerts := make([]*Alert, 0, len(fc.Features))
+		for _, f := range fc.Features {
+			a, err := alertFromFeature(f)
+			if err != nil {
+				return nil, err
+			}
+
+			alerts = append(alerts, a)
+		}
+
+		return alerts, nil
+	}
+
+	return nil, fmt.Errorf("%w: unexpected GeoJSON type %q", ErrInvalidAlert, head.Type)
+}
+
+func alertFromFeature(f *geojson.Feature) (*Alert, error) {
+	// round trip the properties through JSON to decode them into a struct
+	raw, err := json.Marshal(f.Properties)
+	if err != nil {
+		return nil, err
+	}
+
+	var p nwsProperties
+	if err = json.Unmarshal(raw, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v: %w", ErrInvalidAlert, f.ID, err)
+	}
+
+	a := &Alert{
+		ID:            fmt.Sprintf("%v", f.ID),
+		Identifier:    p.ID,
+		Sender:        p.Sender,
+		SenderName:    p.SenderName,
+		Status:        p.Status,
+		MessageType:   p.MessageType,
+		Category:      p.Category,
+		Event:         p.Event,
+		Headline:      p.Headline,
+		Description:   p.Description,
+		Instruction:   p.Instruction,
+		Severity:      Severity(p.Severity),
+		Urgency:       Urgency(p.Urgency),
+		Certainty:     normalizeCertainty(p.Certainty),
+		Sent:          deref(p.Sent),
+		Effective:     deref(p.Effective),
+		Onset:         deref(p.Onset),
+		Expires:       deref(p.Expires),
+		Ends:          deref(p.Ends),
+		AreaDesc:      p.AreaDesc,
+		AffectedZones: p.AffectedZones,
+		UGC:           p.Geocode.UGC,
+		SAME:          p.Geocode.SAME,
+		VTEC:          p.Parameters["VTEC"],
+	}
+
+	if f.ID == nil {
+		a.ID = p.ID
+	}
+
+	for _, ref := range p.References {
+		a.References = append(a.References, ref.Identifier)
+	}
+
+	if f.Geometry != nil {
+		a.Polygon = geodata.FromOrbGeometry(f.Geometry)
+	}
+
+	return a, nil
+}
+
+func deref(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+
+	return *t
+}
@@ -0,0 +1,113 @@
+package alerts
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/paulmach/orb"
+)
+
+func decodeGeoJSONFile(t *testing.T, name string) []*Alert {
+	t.Helper()
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	alerts, err := DecodeGeoJSON(f)
+	if err != nil {
+		t.Fatalf("DecodeGeoJSON(%s) = %v", name, err)
+	}
+
+	return alerts
+}
+
+func TestDecodeGeoJSON(t *testing.T) {
+	alerts := decodeGeoJSONFile(t, "testdata/nws-alerts.json")
+	if len(alerts) != 2 {
+		t.Fatalf("decoded %d alerts, want 2", len(alerts))
+	}
+
+	edt := time.FixedZone("EDT", -4*60*60)
+
+	redFlag := alerts[0]
+	if !strings.HasPrefix(redFlag.ID, "https://api.weather.gov/alerts/urn:oid:") ||
+		!strings.HasPrefix(redFlag.Identifier, "urn:oid:") {
+		t.Errorf("ID, Identifier = %q, %q, want the alert URL and its URN", redFlag.ID, redFlag.Identifier)
+	}
+
+	if redFlag.Event != "Red Flag Warning" || redFlag.Severity != SeveritySevere ||
+		redFlag.Urgency != UrgencyExpected || redFlag.Certainty != CertaintyLikely {
+		t.Errorf("event = %q %s %s %s", redFlag.Event, redFlag.Severity, redFlag.Urgency, redFlag.Certainty)
+	}
+
+	if want := time.Date(2025, time.October, 18, 12, 0, 0, 0, edt); !redFlag.Onset.Equal(want) {
+		t.Errorf("Onset = %s, want %s", redFlag.Onset, want)
+	}
+
+	if want := []string{"OHZ055", "OHZ056"}; !slices.Equal(redFlag.UGC, want) {
+		t.Errorf("UGC = %v, want %v", redFlag.UGC, want)
+	}
+
+	if want := []string{"039049", "039097"}; !slices.Equal(redFlag.SAME, want) {
+		t.Errorf("SAME = %v, want %v", redFlag.SAME, want)
+	}
+
+	if want := []string{"https://api.weather.gov/zones/fire/OHZ055", "https://api.weather.gov/zones/fire/OHZ056"}; !slices.Equal(redFlag.AffectedZones, want) {
+		t.Errorf("AffectedZones = %v, want %v", redFlag.AffectedZones, want)
+	}
+
+	if want := []string{"/O.NEW.KILN.FW.W.0003.251018T1600Z-251019T0000Z/"}; !slices.Equal(redFlag.VTEC, want) {
+		t.Errorf("VTEC = %v, want %v", redFlag.VTEC, want)
+	}
+
+	if redFlag.Polygon != nil || len(redFlag.References) != 0 {
+		t.Errorf("Polygon, References = %v, %v, want neither", redFlag.Polygon, redFlag.References)
+	}
+
+	storm := alerts[1]
+	if storm.MessageType != "Update" || len(storm.References) != 1 || !strings.HasSuffix(storm.References[0], ".001.1") {
+		t.Errorf("MessageType, References = %q, %v, want an update of .001.1", storm.MessageType, storm.References)
+	}
+
+	// ends is null, so the alert ends when it expires
+	if want := time.Date(2025, time.October, 18, 18, 15, 0, 0, edt); !storm.Ends.IsZero() || !storm.End().Equal(want) {
+		t.Errorf("Ends, End() = %s, %s, want zero and %s", storm.Ends, storm.End(), want)
+	}
+
+	if p, ok := storm.Polygon.AsOrbGeometry().(orb.Polygon); !ok || len(p[0]) != 5 || p[0][0] != (orb.Point{-83.21, 39.95}) {
+		t.Errorf("Polygon = %v, want the warning polygon", storm.Polygon.AsOrbGeometry())
+	}
+}
+
+func TestDecodeGeoJSONFeature(t *testing.T) {
+	const feature = `{"type":"Feature","geometry":null,"properties":{"id":"urn:oid:1","event":"Flood Watch",` +
+		`"certainty":"Very Likely","geocode":{"UGC":["OHZ003"]}}}`
+
+	alerts, err := DecodeGeoJSON(strings.NewReader(feature))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(alerts) != 1 || alerts[0].ID != "urn:oid:1" || alerts[0].Certainty != CertaintyLikely {
+		t.Errorf("DecodeGeoJSON() = %+v, want one alert with the properties ID", alerts)
+	}
+}
+
+func TestDecodeGeoJSONErrors(t *testing.T) {
+	for _, doc := range []string{
+		`{"type":"Point","coordinates":[0,0]}`,
+		`{"type":"Feature",`,
+		`{"type":"FeatureCollection","features":[{"type":"Feature","properties":{"sent":"yesterday"}}]}`,
+	} {
+		if _, err := DecodeGeoJSON(strings.NewReader(doc)); !errors.Is(err, ErrInvalidAlert) {
+			t.Errorf("DecodeGeoJSON(%s) = %v, want %v", doc, err, ErrInvalidAlert)
+		}
+	}
+}
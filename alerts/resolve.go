@@ -0,0 +1,161 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/watchedsky-social/libwatchedsky"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+	"github.com/watchedsky-social/libwatchedsky/geodata/ugc"
+	"github.com/watchedsky-social/libwatchedsky/vtec"
+)
+
+// AffectedZones are the zones an alert applies to
+type AffectedZones struct {
+	// OIDs are the OIDs of every matched zone, sorted and without duplicates
+	OIDs []string `json:"oids"`
+	// NotFound lists the zone IDs, UGC codes and SAME codes that match no active zone
+	NotFound []string `json:"notFound,omitempty"`
+	// Zips are the zip codes inside the polygon of a storm-based warning. They are only set for
+	// alerts with a polygon, since those are the users who should be notified rather than
+	// everyone in the affected zones
+	Zips []geodata.ZipCoverage `json:"zips,omitempty"`
+}
+
+// Resolver maps the areas of alerts to zones in a geodata DB
+type Resolver struct {
+	store *geodata.Store
+	ugc   *ugc.Resolver
+}
+
+// NewResolver creates a [Resolver] that queries s
+func NewResolver(s *geodata.Store) *Resolver {
+	return &Resolver{store: s, ugc: ugc.NewResolver(s)}
+}
+
+// Resolve returns the zones an alert applies to. Alerts from the NWS API list them in
+// AffectedZones, which are matched exactly. Otherwise the UGC codes of a are resolved to the zone
+// types they can refer to: marine codes to coastal and offshore zones, other zone codes to fire
+// zones in fire weather products (VTEC phenomena FW) and to public zones in everything else. SAME
+// codes only name whole counties or states, so they are only used for alerts that carry no UGC
+// codes. The zip codes inside the polygon of the alert are included if it has one
+func (r *Resolver) Resolve(ctx context.Context, a *Alert) (*AffectedZones, error) {
+	if ctx == nil {
+		return nil, libwatchedsky.ErrNilContext
+	}
+
+	seen := map[string]bool{}
+	affected := &AffectedZones{}
+	add := func(oid string) {
+		if !seen[oid] {
+			seen[oid] = true
+			affected.OIDs = append(affected.OIDs, oid)
+		}
+	}
+	addResolution := func(res *ugc.Resolution) {
+		for _, oid := range res.AllOIDs() {
+			add(oid)
+		}
+		affected.NotFound = append(affected.NotFound, res.NotFound...)
+	}
+
+	var codes []ugc.Code
+	for _, code := range a.UGC {
+		u, err := ugc.Parse(code)
+		if err != nil {
+			return nil, err
+		}
+
+		codes = append(codes, u.Codes...)
+	}
+
+	switch {
+	case len(a.AffectedZones) > 0:
+		res, err := r.ugc.ResolveZoneIDs(ctx, a.AffectedZones)
+		if err != nil {
+			return nil, err
+		}
+		addResolution(res)
+	case len(codes) > 0:
+		fire, err := isFireWeather(a)
+		if err != nil {
+			return nil, err
+		}
+
+		var land, marine []ugc.Code
+		for _, c := range codes {
+			if c.Marine() {
+				marine = append(marine, c)
+			} else {
+				land = append(land, c)
+			}
+		}
+
+		landTypes := []string{"county", "public"}
+		if fire {
+			landTypes = []string{"county", "fire"}
+		}
+
+		for _, group := range []struct {
+			codes []ugc.Code
+			types []string
+		}{
+			{land, landTypes},
+			{marine, []string{"coastal", "offshore"}},
+		} {
+			if len(group.codes) == 0 {
+				continue
+			}
+
+			res, err := r.ugc.Resolve(ctx, group.codes, ugc.WithZoneTypes(group.types...))
+			if err != nil {
+				return nil, err
+			}
+			addResolution(res)
+		}
+	default:
+		for _, code := range a.SAME {
+			zones, err := r.store.ZonesBySAME(ctx, code)
+			if errors.Is(err, geodata.ErrZoneNotFound) || (err == nil && len(zones) == 0) {
+				affected.NotFound = append(affected.NotFound, code)
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			for _, z := range zones {
+				add(z.OID())
+			}
+		}
+	}
+
+	if a.Polygon != nil {
+		zips, err := r.store.ZipsWithin(ctx, a.Polygon)
+		if err != nil {
+			return nil, err
+		}
+		affected.Zips = zips
+	}
+
+	sort.Strings(affected.OIDs)
+	return affected, nil
+}
+
+// isFireWeather reports whether a is a fire weather product, whose zone codes refer to fire zones
+func isFireWeather(a *Alert) (bool, error) {
+	for _, s := range a.VTEC {
+		v, err := vtec.Parse(s)
+		if err != nil {
+			return false, err
+		}
+
+		if v.Phenomena == "FW" {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
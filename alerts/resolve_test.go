@@ -0,0 +1,164 @@
+package alerts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/watchedsky-social/libwatchedsky"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+
+	// registers the plain sqlite3 driver as well as spatialite, which resolving codes does not need
+	_ "github.com/watchedsky-social/go-spatialite"
+)
+
+// newTestResolver returns a Resolver over a DB with just enough of the zones table to resolve zone
+// IDs and UGC codes. It has no spatial functions, so anything that looks up SAME codes or zip codes
+// fails
+func newTestResolver(t *testing.T) *Resolver {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if _, err = db.Exec(`CREATE TABLE zones (oid TEXT NOT NULL PRIMARY KEY, id TEXT NOT NULL UNIQUE,
+  type TEXT NOT NULL, retired_at TIMESTAMP DEFAULT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, z := range []struct {
+		oid, zoneType, shortID string
+		retired                bool
+	}{
+		{"fire-55", "fire", "OHZ055", false},
+		{"fire-56", "fire", "OHZ056", false},
+		{"public-55", "public", "OHZ055", false},
+		{"public-56", "public", "OHZ056", false},
+		{"county-49", "county", "OHC049", false},
+		{"coastal-142", "coastal", "LEZ142", false},
+		{"coastal-143", "coastal", "LEZ143", true},
+	} {
+		var retiredAt any
+		if z.retired {
+			retiredAt = "2025-01-01 00:00:00"
+		}
+
+		if _, err = db.Exec(`INSERT INTO zones (oid, id, type, retired_at) VALUES (?, ?, ?, ?)`,
+			z.oid, geodata.NWSAPIZoneID(z.zoneType, z.shortID), z.zoneType, retiredAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return NewResolver(geodata.NewStore(db))
+}
+
+func TestResolve(t *testing.T) {
+	r := newTestResolver(t)
+	ctx := context.Background()
+
+	nws := decodeGeoJSONFile(t, "testdata/nws-alerts.json")[0]
+	redFlag := decodeCAPFile(t, "testdata/cap-red-flag.xml")
+
+	noVTEC := *redFlag
+	noVTEC.VTEC = nil
+
+	unknownZone := *nws
+	unknownZone.AffectedZones = append(slices.Clone(nws.AffectedZones), "https://api.weather.gov/zones/fire/OHZ099")
+
+	county := *redFlag
+	county.UGC = []string{"OHC049", "OHZ055"}
+
+	tests := []struct {
+		name     string
+		alert    *Alert
+		oids     []string
+		notFound []string
+	}{
+		{
+			name:  "affected zones are matched exactly",
+			alert: nws,
+			oids:  []string{"fire-55", "fire-56"},
+		},
+		{
+			name:     "unknown affected zone",
+			alert:    &unknownZone,
+			oids:     []string{"fire-55", "fire-56"},
+			notFound: []string{"https://api.weather.gov/zones/fire/OHZ099"},
+		},
+		{
+			name:  "fire weather codes are fire zones",
+			alert: redFlag,
+			oids:  []string{"fire-55", "fire-56"},
+		},
+		{
+			name:  "other codes are public zones",
+			alert: &noVTEC,
+			oids:  []string{"public-55", "public-56"},
+		},
+		{
+			name:  "county codes with zone codes",
+			alert: &county,
+			oids:  []string{"county-49", "fire-55"},
+		},
+		{
+			name:     "marine codes are coastal or offshore zones",
+			alert:    decodeCAPFile(t, "testdata/cap-small-craft.xml"),
+			oids:     []string{"coastal-142"},
+			notFound: []string{"LEZ143"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// every alert also has SAME codes, which would fail to resolve without spatial
+			// functions if they were used
+			affected, err := r.Resolve(ctx, tt.alert)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(affected.OIDs, tt.oids) {
+				t.Errorf("OIDs = %v, want %v", affected.OIDs, tt.oids)
+			}
+
+			if !slices.Equal(affected.NotFound, tt.notFound) {
+				t.Errorf("NotFound = %v, want %v", affected.NotFound, tt.notFound)
+			}
+		})
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	r := newTestResolver(t)
+	ctx := context.Background()
+	redFlag := decodeCAPFile(t, "testdata/cap-red-flag.xml")
+
+	if _, err := r.Resolve(nil, redFlag); !errors.Is(err, libwatchedsky.ErrNilContext) {
+		t.Errorf("Resolve(nil) = %v, want %v", err, libwatchedsky.ErrNilContext)
+	}
+
+	badVTEC := *redFlag
+	badVTEC.VTEC = []string{"/O.NEW.KILN.FW.W/"}
+	if _, err := r.Resolve(ctx, &badVTEC); err == nil {
+		t.Error("Resolve() with a bad VTEC string = nil, want an error")
+	}
+
+	// without UGC codes the SAME codes are looked up, which needs spatial functions this DB lacks
+	sameOnly := *redFlag
+	sameOnly.UGC = nil
+	if _, err := r.Resolve(ctx, &sameOnly); err == nil {
+		t.Error("Resolve() with only SAME codes = nil, want them looked up")
+	}
+
+	badUGC := *redFlag
+	badUGC.UGC = []string{"OHX055"}
+	if _, err := r.Resolve(ctx, &badUGC); err == nil {
+		t.Error("Resolve() with a bad UGC code = nil, want an error")
+	}
+}
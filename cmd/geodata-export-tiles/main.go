@@ -0,0 +1,61 @@
+// Command geodata-export-tiles bakes the zone layers of a geodata DB into a PMTiles archive that
+// can be served statically, such as from the bucket the DB snapshot is saved to.
+//
+//	geodata-export-tiles -db geodata.db -out zones.pmtiles [-min-zoom 0] [-max-zoom 10] [-types county,public]
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	_ "github.com/watchedsky-social/go-spatialite"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+	"github.com/watchedsky-social/libwatchedsky/tiles"
+)
+
+func main() {
+	dbPath := flag.String("db", "", "path to the geodata SQLite DB")
+	out := flag.String("out", "zones.pmtiles", "path of the PMTiles archive to write")
+	minZoom := flag.Uint("min-zoom", tiles.DefaultMinZoom, "lowest zoom to export")
+	maxZoom := flag.Uint("max-zoom", tiles.DefaultMaxZoom, "highest zoom to export")
+	types := flag.String("types", "", "comma separated zone types to export (default: all)")
+	flag.Parse()
+
+	if err := run(*dbPath, *out, *minZoom, *maxZoom, *types); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(dbPath, out string, minZoom, maxZoom uint, types string) error {
+	if dbPath == "" {
+		return fmt.Errorf("-db is required")
+	}
+
+	if minZoom > maxZoom || maxZoom > 24 {
+		return fmt.Errorf("invalid zoom range %d-%d", minZoom, maxZoom)
+	}
+
+	var tileOpts []tiles.Option
+	if types != "" {
+		tileOpts = append(tileOpts, tiles.WithZoneTypes(strings.Split(types, ",")...))
+	}
+
+	db, err := sql.Open("spatialite", fmt.Sprintf("file:%s?mode=ro", dbPath))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	return tiles.ExportPMTilesFile(ctx, geodata.NewStore(db), out,
+		tiles.WithZoomRange(uint8(minZoom), uint8(maxZoom)),
+		tiles.WithTileOptions(tileOpts...))
+}
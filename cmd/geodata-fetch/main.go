@@ -0,0 +1,144 @@
+// Command geodata-fetch populates a source data root for the geodata migrations.
+//
+//	geodata-fetch -root ./data [-only nws-zones,zip-codes] [-fixes-url URL -fixes OHC035.wkt,...]
+//	geodata-fetch -root ./data -only none -nws-shapefiles URL,...
+//	geodata-fetch -root ./data -only none -zcta -timezones
+//	geodata-fetch -root ./data -verify
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/watchedsky-social/libwatchedsky/geodata/fetch"
+)
+
+func main() {
+	root := flag.String("root", ".", "source data root to write into")
+	only := flag.String("only", "", "comma separated dataset names to fetch (default: all)")
+	nwsURL := flag.String("nws-url", fetch.DefaultNWSAPIURL, "base URL of the NWS API")
+	zipURL := flag.String("zip-url", fetch.DefaultZipCodesURL, "URL of the zip code database CSV")
+	fixesURL := flag.String("fixes-url", "", "base URL that manual fix files are downloaded from")
+	fixes := flag.String("fixes", "", "comma separated manual fix file names")
+	shapefiles := flag.String("nws-shapefiles", "", "comma separated URLs of zipped NWS zone shapefiles")
+	zcta := flag.Bool("zcta", false, "also fetch the Census ZCTA boundaries used for zip code areas")
+	zctaURL := flag.String("zcta-url", fetch.DefaultZCTAURL, "URL of the zipped Census ZCTA shapefile")
+	timeZones := flag.Bool("timezones", false, "also fetch the time zone boundaries assigned to zones and zip codes")
+	timeZonesURL := flag.String("timezones-url", fetch.DefaultTimeZonesURL, "URL of the zipped time zone boundary shapefile")
+	verify := flag.Bool("verify", false, "verify the files in the manifest instead of fetching")
+	flag.Parse()
+
+	if err := run(*root, *only, *nwsURL, *zipURL, *fixesURL, *fixes, *shapefiles, optionalSource(*zcta, *zctaURL),
+		optionalSource(*timeZones, *timeZonesURL), *verify); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(root, only, nwsURL, zipURL, fixesURL, fixes, shapefiles, zctaURL, timeZonesURL string, verify bool) error {
+	if verify {
+		m, err := fetch.ReadManifest(root)
+		if err != nil {
+			return err
+		}
+
+		return m.Verify(root)
+	}
+
+	datasets := []fetch.Dataset{fetch.NWSZones(nwsURL), fetch.ZipCodes(zipURL)}
+	if fixes != "" {
+		if fixesURL == "" {
+			return fmt.Errorf("-fixes-url is required with -fixes")
+		}
+
+		datasets = append(datasets, fetch.ManualFixes(fixesURL, splitList(fixes)...)...)
+	}
+
+	// shapefiles are always fetched when given, so "-only none" fetches nothing else
+	var extra []fetch.Dataset
+	if shapefiles != "" {
+		extra = fetch.NWSShapefiles(splitList(shapefiles)...)
+	}
+
+	if zctaURL != "" {
+		extra = append(extra, fetch.ZCTAShapefile(zctaURL))
+	}
+
+	if timeZonesURL != "" {
+		extra = append(extra, fetch.TimeZoneShapefile(timeZonesURL))
+	}
+
+	if only != "" {
+		selected, err := selectDatasets(datasets, splitList(only))
+		if err != nil {
+			return err
+		}
+		datasets = selected
+	}
+	datasets = append(datasets, extra...)
+
+	f, err := fetch.New(root)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	return f.Fetch(ctx, datasets...)
+}
+
+// selectDatasets returns the datasets named in wanted. "none" selects nothing, and any other
+// name that matches no dataset is an error, so that a typo does not silently fetch nothing
+func selectDatasets(datasets []fetch.Dataset, wanted []string) ([]fetch.Dataset, error) {
+	var selected []fetch.Dataset
+	for _, name := range wanted {
+		if name == "none" {
+			continue
+		}
+
+		found := false
+		for _, ds := range datasets {
+			if ds.Name == name {
+				selected = append(selected, ds)
+				found = true
+			}
+		}
+
+		if !found {
+			names := make([]string, 0, len(datasets))
+			for _, ds := range datasets {
+				names = append(names, ds.Name)
+			}
+
+			return nil, fmt.Errorf("-only: unknown dataset %q (want none or one of %s)", name,
+				strings.Join(names, ", "))
+		}
+	}
+
+	return selected, nil
+}
+
+// optionalSource returns the URL of an optional dataset to fetch, or "" if it was not requested
+func optionalSource(enabled bool, srcURL string) string {
+	if !enabled {
+		return ""
+	}
+
+	return srcURL
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,90 @@
+// Command geodata-update-zones applies a new NWS zone release to an existing geodata DB, without
+// rebuilding it, and prints a JSON report of the zones that changed.
+//
+//	geodata-update-zones -db geodata.db -source all.json [-effective 2025-10-17] [-overrides dir] [-dry-run]
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	_ "github.com/watchedsky-social/go-spatialite"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+	"github.com/watchedsky-social/libwatchedsky/topology"
+)
+
+func main() {
+	dbPath := flag.String("db", "", "path to the geodata SQLite DB")
+	source := flag.String("source", "", "NWS zone GeoJSON or zipped shapefile")
+	effective := flag.String("effective", "", "date (YYYY-MM-DD) or RFC 3339 time the release takes effect (default: now)")
+	overrides := flag.String("overrides", "", "directory of manual geometry fixes and metadata patches")
+	dryRun := flag.Bool("dry-run", false, "report changes without applying them")
+	flag.Parse()
+
+	if err := run(*dbPath, *source, *effective, *overrides, *dryRun); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(dbPath, source, effective, overrides string, dryRun bool) error {
+	if dbPath == "" || source == "" {
+		return fmt.Errorf("-db and -source are required")
+	}
+
+	// changed zones are simplified on their own, so their neighbors are resimplified to match them
+	opts := geodata.ZoneUpdateOptions{DryRun: dryRun, SimplifyZones: topology.ZoneSimplifier()}
+	if effective != "" {
+		t, err := parseTime(effective)
+		if err != nil {
+			return err
+		}
+		opts.EffectiveAt = t
+	}
+
+	if overrides != "" {
+		o, err := geodata.LoadZoneOverrides(overrides)
+		if err != nil {
+			return err
+		}
+		opts.Overrides = o
+	}
+
+	db, err := sql.Open("spatialite", fmt.Sprintf("file:%s", dbPath))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	r, err := geodata.OpenNWSZones(source)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := geodata.NewStore(db).UpdateZones(ctx, r, opts)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,154 @@
+package geodata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+var (
+	// ErrWriterClosed is returned when a feature is written to a closed [FeatureCollectionWriter]
+	ErrWriterClosed = errors.New("feature collection writer is closed")
+)
+
+// ToFeature returns the zone as a GeoJSON Feature. The feature ID is the OID of the zone, and its
+// properties are the zone's ID, name, type, metadata and center
+func (z *Zone) ToFeature() *geojson.Feature {
+	var g, center orb.Geometry
+	if z.Geometry != nil {
+		g = z.Geometry.AsOrbGeometry()
+	}
+
+	if z.Center != nil {
+		center = z.Center.AsOrbGeometry()
+	}
+
+	f := geojson.NewFeature(g)
+	f.ID = z.OID()
+	f.Properties["id"] = z.ID
+	f.Properties["name"] = z.Name
+	f.Properties["type"] = z.Type
+	f.Properties["metadata"] = z.Metadata
+	f.Properties["center"] = geojson.NewGeometry(center)
+
+	return f
+}
+
+// MarshalJSON implements [encoding/json.Marshaler], encoding the zone as a GeoJSON Feature
+func (z *Zone) MarshalJSON() ([]byte, error) {
+	return z.ToFeature().MarshalJSON()
+}
+
+// UnmarshalJSON implements [encoding/json.Unmarshaler], decoding a GeoJSON Feature written by
+// [Zone.MarshalJSON]
+func (z *Zone) UnmarshalJSON(data []byte) error {
+	var f struct {
+		ID         string            `json:"id"`
+		Geometry   *geojson.Geometry `json:"geometry"`
+		Properties struct {
+			ID       string            `json:"id"`
+			Name     string            `json:"name"`
+			Type     string            `json:"type"`
+			Metadata JSONB             `json:"metadata"`
+			Center   *geojson.Geometry `json:"center"`
+		} `json:"properties"`
+	}
+
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+
+	*z = Zone{
+		oid:      f.ID,
+		ID:       f.Properties.ID,
+		Name:     f.Properties.Name,
+		Type:     f.Properties.Type,
+		Metadata: f.Properties.Metadata,
+		Center:   fromGeoJSONGeometry(f.Properties.Center),
+		Geometry: fromGeoJSONGeometry(f.Geometry),
+	}
+
+	return nil
+}
+
+// fromGeoJSONGeometry returns g as a [Geometry], or nil for a null geometry
+func fromGeoJSONGeometry(g *geojson.Geometry) *Geometry {
+	if g == nil {
+		return nil
+	}
+
+	return FromOrbGeometry(g.Geometry())
+}
+
+// FeatureCollectionWriter streams zones to a GeoJSON FeatureCollection one at a time, so that a
+// large result set never has to be held in memory. The collection is complete once the writer is
+// closed
+type FeatureCollectionWriter struct {
+	w      io.Writer
+	count  int
+	closed bool
+}
+
+// NewFeatureCollectionWriter creates a [FeatureCollectionWriter] that writes to w
+func NewFeatureCollectionWriter(w io.Writer) *FeatureCollectionWriter {
+	return &FeatureCollectionWriter{w: w}
+}
+
+// WriteZone writes z as the next feature of the collection
+func (fw *FeatureCollectionWriter) WriteZone(z *Zone) error {
+	return fw.WriteFeature(z.ToFeature())
+}
+
+// WriteFeature writes f as the next feature of the collection
+func (fw *FeatureCollectionWriter) WriteFeature(f *geojson.Feature) error {
+	if fw.closed {
+		return ErrWriterClosed
+	}
+
+	data, err := f.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("%v: %w", f.ID, err)
+	}
+
+	sep := ",\n"
+	if fw.count == 0 {
+		sep = `{"type":"FeatureCollection","features":[` + "\n"
+	}
+
+	if _, err = io.WriteString(fw.w, sep); err != nil {
+		return err
+	}
+
+	if _, err = fw.w.Write(data); err != nil {
+		return err
+	}
+
+	fw.count++
+	return nil
+}
+
+// Count returns the number of features written so far
+func (fw *FeatureCollectionWriter) Count() int {
+	return fw.count
+}
+
+// Close ends the collection. A collection with no features is written as an empty one. Close
+// does not close the underlying writer
+func (fw *FeatureCollectionWriter) Close() error {
+	if fw.closed {
+		return nil
+	}
+	fw.closed = true
+
+	end := "\n]}\n"
+	if fw.count == 0 {
+		end = `{"type":"FeatureCollection","features":[]}` + "\n"
+	}
+
+	_, err := io.WriteString(fw.w, end)
+	return err
+}
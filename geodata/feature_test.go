@@ -0,0 +1,155 @@
+package geodata
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// testZone returns a zone with every field set, as ingested from the NWS
+func testZone() *Zone {
+	return NewZoneFromFeature("us", &geojson.Feature{
+		ID: "https://api.weather.gov/zones/county/OHC061",
+		Geometry: orb.Polygon{
+			{{-84.8, 39}, {-84.2, 39}, {-84.2, 39.3}, {-84.8, 39.3}, {-84.8, 39}},
+		},
+		Properties: geojson.Properties{
+			"name":  "Hamilton",
+			"type":  "county",
+			"state": "OH",
+			"cwa":   []any{"ILN"},
+		},
+	})
+}
+
+func TestZoneJSON(t *testing.T) {
+	full := testZone()
+
+	bare := *full
+	bare.Center, bare.Geometry = nil, nil
+
+	tests := []struct {
+		name string
+		zone *Zone
+	}{
+		{name: "every field", zone: full},
+		{name: "no center or geometry", zone: &bare},
+		{name: "empty", zone: &Zone{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.zone)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got Zone
+			if err = json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal(%s) = %v", data, err)
+			}
+
+			if got.OID() != tt.zone.OID() || got.ID != tt.zone.ID || got.Name != tt.zone.Name || got.Type != tt.zone.Type {
+				t.Errorf("zone = %s %s %s %s, want %s %s %s %s", got.OID(), got.ID, got.Name, got.Type,
+					tt.zone.OID(), tt.zone.ID, tt.zone.Name, tt.zone.Type)
+			}
+
+			if !reflect.DeepEqual(got.Metadata, tt.zone.Metadata) {
+				t.Errorf("Metadata = %v, want %v", got.Metadata, tt.zone.Metadata)
+			}
+
+			for _, g := range []struct {
+				name      string
+				got, want *Geometry
+			}{
+				{"Center", got.Center, tt.zone.Center},
+				{"Geometry", got.Geometry, tt.zone.Geometry},
+			} {
+				if (g.got == nil) != (g.want == nil) ||
+					(g.got != nil && !reflect.DeepEqual(g.got.AsOrbGeometry(), g.want.AsOrbGeometry())) {
+					t.Errorf("%s = %v, want %v", g.name, g.got, g.want)
+				}
+			}
+		})
+	}
+}
+
+func TestZoneUnmarshalJSONErrors(t *testing.T) {
+	var z Zone
+	if err := json.Unmarshal([]byte(`{"type":"Feature","properties":{"center":{"type":"Blob"}}}`), &z); err == nil {
+		t.Error("Unmarshal() with an unknown center type = nil, want an error")
+	}
+}
+
+func TestFeatureCollectionWriter(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		var buf bytes.Buffer
+		fw := NewFeatureCollectionWriter(&buf)
+		if err := fw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		fc, err := geojson.UnmarshalFeatureCollection(buf.Bytes())
+		if err != nil || len(fc.Features) != 0 || fw.Count() != 0 {
+			t.Errorf("wrote %q, want an empty FeatureCollection", buf.String())
+		}
+	})
+
+	t.Run("several features", func(t *testing.T) {
+		var buf bytes.Buffer
+		fw := NewFeatureCollectionWriter(&buf)
+
+		zone := testZone()
+		bare := *zone
+		bare.Center, bare.Geometry = nil, nil
+
+		point := geojson.NewFeature(orb.Point{-84.5, 39.1})
+		point.ID = "point"
+
+		for _, write := range []func() error{
+			func() error { return fw.WriteZone(zone) },
+			func() error { return fw.WriteZone(&bare) },
+			func() error { return fw.WriteFeature(point) },
+		} {
+			if err := write(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := fw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// closing again writes nothing more
+		if err := fw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		fc, err := geojson.UnmarshalFeatureCollection(buf.Bytes())
+		if err != nil {
+			t.Fatalf("wrote %q, which is not a FeatureCollection: %v", buf.String(), err)
+		}
+
+		var ids []any
+		for _, f := range fc.Features {
+			ids = append(ids, f.ID)
+		}
+
+		if want := []any{zone.OID(), zone.OID(), "point"}; !reflect.DeepEqual(ids, want) || fw.Count() != 3 {
+			t.Errorf("IDs = %v with Count() = %d, want %v", ids, fw.Count(), want)
+		}
+
+		if fc.Features[1].Geometry != nil {
+			t.Errorf("zone without a geometry was written with %v", fc.Features[1].Geometry)
+		}
+
+		if err = fw.WriteZone(zone); !errors.Is(err, ErrWriterClosed) {
+			t.Errorf("WriteZone() after Close() = %v, want %v", err, ErrWriterClosed)
+		}
+	})
+}
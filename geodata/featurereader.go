@@ -0,0 +1,314 @@
+package geodata
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paulmach/orb/geojson"
+)
+
+// FeatureReader yields features one at a time. Next returns io.EOF when there are no more
+// features
+type FeatureReader interface {
+	Next() (*geojson.Feature, error)
+}
+
+// FeatureReadCloser is a [FeatureReader] backed by an open file
+type FeatureReadCloser interface {
+	FeatureReader
+	io.Closer
+}
+
+var (
+	// ErrUnknownGeoJSONLayout is returned when a GeoJSON stream is not a FeatureCollection, an
+	// array of features, or a GeoJSON text sequence
+	ErrUnknownGeoJSONLayout = errors.New("unrecognized GeoJSON layout")
+)
+
+// recordSeparator prefixes each record of an RFC 8142 GeoJSON text sequence
+const recordSeparator = 0x1E
+
+type geojsonLayout int
+
+const (
+	layoutUnknown geojsonLayout = iota
+	layoutArray
+	layoutCollection
+	layoutSequence
+)
+
+// GeoJSONReader streams features out of a GeoJSON document without loading it into memory. The
+// layout is detected from the start of the stream and may be any of
+//   - a FeatureCollection object
+//   - a bare JSON array of Features
+//   - a sequence of Features, either newline delimited or as an RFC 8142 text sequence
+type GeoJSONReader struct {
+	decoder *json.Decoder
+	layout  geojsonLayout
+	pending *geojson.Feature
+	done    bool
+}
+
+// NewGeoJSONReader creates a [GeoJSONReader]. Detection errors are reported by the first call to
+// [GeoJSONReader.Next]
+func NewGeoJSONReader(r io.Reader) *GeoJSONReader {
+	return &GeoJSONReader{decoder: json.NewDecoder(&rsFilter{r: bufio.NewReader(r)})}
+}
+
+// Next implements [FeatureReader]
+func (g *GeoJSONReader) Next() (*geojson.Feature, error) {
+	if g.done {
+		return nil, io.EOF
+	}
+
+	if g.layout == layoutUnknown {
+		if err := g.detect(); err != nil {
+			g.done = true
+			return nil, err
+		}
+	}
+
+	if g.pending != nil {
+		f := g.pending
+		g.pending = nil
+		return f, nil
+	}
+
+	switch g.layout {
+	case layoutSequence:
+		var f geojson.Feature
+		if err := g.decoder.Decode(&f); err != nil {
+			g.done = true
+			return nil, err
+		}
+
+		return &f, nil
+	default:
+		if !g.decoder.More() {
+			g.done = true
+			// consume the closing bracket so truncated documents are reported
+			if _, err := g.decoder.Token(); err != nil {
+				return nil, err
+			}
+
+			return nil, io.EOF
+		}
+
+		var f geojson.Feature
+		if err := g.decoder.Decode(&f); err != nil {
+			g.done = true
+			return nil, err
+		}
+
+		return &f, nil
+	}
+}
+
+// detect reads just enough of the stream to tell which layout it uses, and leaves the decoder
+// positioned at the first feature
+func (g *GeoJSONReader) detect() error {
+	tok, err := g.decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('['):
+		g.layout = layoutArray
+		return nil
+	case json.Delim('{'):
+	default:
+		return fmt.Errorf("%w: starts with %v", ErrUnknownGeoJSONLayout, tok)
+	}
+
+	// the document is either a FeatureCollection or the first Feature of a sequence. Walk its
+	// members until one of them gives it away, holding on to the ones already read in case it
+	// turns out to be a Feature that has to be decoded in full
+	members := map[string]json.RawMessage{}
+	for g.decoder.More() {
+		keyTok, err := g.decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		key, _ := keyTok.(string)
+		if key == "features" {
+			return g.enterFeatures()
+		}
+
+		var raw json.RawMessage
+		if err = g.decoder.Decode(&raw); err != nil {
+			return err
+		}
+
+		if key != "type" {
+			members[key] = raw
+			continue
+		}
+
+		var typ string
+		if err = json.Unmarshal(raw, &typ); err != nil {
+			return err
+		}
+
+		switch typ {
+		case "FeatureCollection":
+			return g.seekFeatures()
+		case "Feature":
+			members[key] = raw
+			return g.finishFirstFeature(members)
+		default:
+			return fmt.Errorf("%w: top level type %q", ErrUnknownGeoJSONLayout, typ)
+		}
+	}
+
+	return fmt.Errorf("%w: object has no type", ErrUnknownGeoJSONLayout)
+}
+
+// seekFeatures skips FeatureCollection members until it reaches "features"
+func (g *GeoJSONReader) seekFeatures() error {
+	for g.decoder.More() {
+		keyTok, err := g.decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		if keyTok == "features" {
+			return g.enterFeatures()
+		}
+
+		var skip json.RawMessage
+		if err = g.decoder.Decode(&skip); err != nil {
+			return err
+		}
+	}
+
+	return fmt.Errorf("%w: FeatureCollection has no features", ErrUnknownGeoJSONLayout)
+}
+
+func (g *GeoJSONReader) enterFeatures() error {
+	tok, err := g.decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	if tok != json.Delim('[') {
+		return fmt.Errorf("%w: features is not an array", ErrUnknownGeoJSONLayout)
+	}
+
+	g.layout = layoutCollection
+	return nil
+}
+
+// finishFirstFeature reads the rest of the first feature of a sequence and queues it
+func (g *GeoJSONReader) finishFirstFeature(members map[string]json.RawMessage) error {
+	for g.decoder.More() {
+		keyTok, err := g.decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		var raw json.RawMessage
+		if err = g.decoder.Decode(&raw); err != nil {
+			return err
+		}
+
+		key, _ := keyTok.(string)
+		members[key] = raw
+	}
+
+	// closing brace
+	if _, err := g.decoder.Token(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return err
+	}
+
+	f, err := geojson.UnmarshalFeature(data)
+	if err != nil {
+		return err
+	}
+
+	g.layout = layoutSequence
+	g.pending = f
+	return nil
+}
+
+// rsFilter turns RFC 8142 record separators into whitespace, so a text sequence can be read
+// with an ordinary [json.Decoder]. A record separator can never appear inside a valid JSON
+// text, since control characters in strings must be escaped
+type rsFilter struct {
+	r io.Reader
+}
+
+func (f *rsFilter) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	for i := range n {
+		if p[i] == recordSeparator {
+			p[i] = '\n'
+		}
+	}
+
+	return n, err
+}
+
+type geojsonFile struct {
+	*GeoJSONReader
+	fp *os.File
+}
+
+func (g *geojsonFile) Close() error {
+	return g.fp.Close()
+}
+
+// OpenGeoJSON opens a GeoJSON file in any of the layouts supported by [GeoJSONReader]
+func OpenGeoJSON(file string) (FeatureReadCloser, error) {
+	fp, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return &geojsonFile{GeoJSONReader: NewGeoJSONReader(fp), fp: fp}, nil
+}
+
+type featureSlice struct {
+	features []*geojson.Feature
+}
+
+func (s *featureSlice) Next() (*geojson.Feature, error) {
+	if len(s.features) == 0 {
+		return nil, io.EOF
+	}
+
+	f := s.features[0]
+	s.features = s.features[1:]
+	return f, nil
+}
+
+func (s *featureSlice) Close() error {
+	return nil
+}
+
+// OpenNWSZones opens a file of NWS zones, which is either a zipped NWS shapefile (see
+// [ReadNWSShapefile]) or GeoJSON in any layout supported by [GeoJSONReader]
+func OpenNWSZones(file string) (FeatureReadCloser, error) {
+	if strings.EqualFold(filepath.Ext(file), ".zip") {
+		features, err := ReadNWSShapefile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		return &featureSlice{features: features}, nil
+	}
+
+	return OpenGeoJSON(file)
+}
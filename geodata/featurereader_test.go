@@ -0,0 +1,141 @@
+package geodata
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const (
+	testFeatureA = `{"type":"Feature","id":"a","geometry":{"type":"Point","coordinates":[-84.5,39.1]},"properties":{"name":"A"}}`
+	testFeatureB = `{"type":"Feature","id":"b","geometry":null,"properties":{"name":"B"}}`
+)
+
+// readIDs reads every feature from r and returns their IDs
+func readIDs(r FeatureReader) ([]string, error) {
+	var ids []string
+	for {
+		f, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return ids, nil
+		}
+
+		if err != nil {
+			return ids, err
+		}
+
+		id, _ := f.ID.(string)
+		ids = append(ids, id)
+	}
+}
+
+func TestGeoJSONReaderDetect(t *testing.T) {
+	tests := []struct {
+		name   string
+		doc    string
+		layout geojsonLayout
+		ids    []string
+	}{
+		{
+			name:   "FeatureCollection",
+			doc:    `{"type":"FeatureCollection","features":[` + testFeatureA + `,` + testFeatureB + `]}`,
+			layout: layoutCollection,
+			ids:    []string{"a", "b"},
+		},
+		{
+			name:   "FeatureCollection with members before features",
+			doc:    `{"type":"FeatureCollection","bbox":[-85,38,-84,40],"name":{"en":"zones"},"features":[` + testFeatureA + `]}`,
+			layout: layoutCollection,
+			ids:    []string{"a"},
+		},
+		{
+			name:   "features before type",
+			doc:    `{"features":[` + testFeatureA + `,` + testFeatureB + `],"type":"FeatureCollection"}`,
+			layout: layoutCollection,
+			ids:    []string{"a", "b"},
+		},
+		{
+			name:   "empty FeatureCollection",
+			doc:    `{"type":"FeatureCollection","features":[]}`,
+			layout: layoutCollection,
+		},
+		{
+			name:   "bare array",
+			doc:    "[\n" + testFeatureA + ",\n" + testFeatureB + "\n]",
+			layout: layoutArray,
+			ids:    []string{"a", "b"},
+		},
+		{
+			name:   "newline delimited",
+			doc:    testFeatureA + "\n" + testFeatureB + "\n",
+			layout: layoutSequence,
+			ids:    []string{"a", "b"},
+		},
+		{
+			name:   "RS-delimited sequence",
+			doc:    "\x1e" + testFeatureA + "\n\x1e" + testFeatureB + "\n",
+			layout: layoutSequence,
+			ids:    []string{"a", "b"},
+		},
+		{
+			name:   "sequence with type after other members",
+			doc:    `{"id":"a","properties":{"name":"A"},"type":"Feature","geometry":null}` + "\n" + testFeatureB,
+			layout: layoutSequence,
+			ids:    []string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewGeoJSONReader(strings.NewReader(tt.doc))
+
+			ids, err := readIDs(r)
+			if err != nil {
+				t.Fatalf("Next() = %v", err)
+			}
+
+			if r.layout != tt.layout {
+				t.Errorf("layout = %d, want %d", r.layout, tt.layout)
+			}
+
+			if !slices.Equal(ids, tt.ids) {
+				t.Errorf("IDs = %v, want %v", ids, tt.ids)
+			}
+
+			if _, err = r.Next(); !errors.Is(err, io.EOF) {
+				t.Errorf("Next() after the end = %v, want %v", err, io.EOF)
+			}
+		})
+	}
+}
+
+func TestGeoJSONReaderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		unknown bool
+	}{
+		{name: "number", doc: `42`, unknown: true},
+		{name: "geometry", doc: `{"type":"Point","coordinates":[0,0]}`, unknown: true},
+		{name: "no type", doc: `{"id":"a"}`, unknown: true},
+		{name: "collection without features", doc: `{"type":"FeatureCollection","bbox":[]}`, unknown: true},
+		{name: "features is not an array", doc: `{"type":"FeatureCollection","features":{}}`, unknown: true},
+		{name: "truncated collection", doc: `{"type":"FeatureCollection","features":[` + testFeatureA},
+		{name: "truncated array", doc: `[` + testFeatureA + `,{"type":"Feat`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readIDs(NewGeoJSONReader(strings.NewReader(tt.doc)))
+			if err == nil {
+				t.Fatal("read every feature, want an error")
+			}
+
+			if errors.Is(err, ErrUnknownGeoJSONLayout) != tt.unknown {
+				t.Errorf("error = %v, want %v: %t", err, ErrUnknownGeoJSONLayout, tt.unknown)
+			}
+		})
+	}
+}
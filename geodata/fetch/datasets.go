@@ -0,0 +1,243 @@
+package fetch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+)
+
+// CombineFunc writes the final contents of a dataset to w, given the raw bodies of each of its
+// sources in the order they were listed
+type CombineFunc func(w io.Writer, sources []io.Reader) error
+
+// Dataset describes a single file in the source data root and where its contents come from
+type Dataset struct {
+	// Name is a short, unique name for the dataset, used for selecting datasets to fetch
+	Name string
+	// Path is the slash-separated location of the file, relative to the source data root
+	Path string
+	// URLs are downloaded in order and passed to Combine
+	URLs []string
+	// Combine builds the file from the downloaded sources. If it is nil, the sources are
+	// concatenated as-is
+	Combine CombineFunc
+}
+
+const (
+	// NWSZonesPath is where the migrations expect the combined NWS zone GeoJSON
+	NWSZonesPath = "us/nws_zone_geojson/all.json"
+	// ZipCodesPath is where the migrations expect the zip code database
+	ZipCodesPath = "us/zip_code_database.csv"
+	// ManualFixesDir is where the migrations look for hand-corrected zone geometries
+	ManualFixesDir = "us/nws_zone_geojson/manual-fixes"
+	// NWSShapefilesDir is where the migrations look for zipped NWS zone shapefiles when there
+	// is no combined GeoJSON, and for County Warning Area shapefiles (w_*.zip)
+	NWSShapefilesDir = "us/nws_zone_shapefiles"
+	// NWSOfficesPath is where the migrations look for forecast office metadata, a JSON array of
+	// objects with id, name, city, state, timeZone and radarStations
+	NWSOfficesPath = "us/nws_offices.json"
+	// ZCTADir is where the migrations look for zipped Census ZCTA shapefiles
+	ZCTADir = "us/zcta"
+	// TimeZonesDir is where the migrations look for IANA time zone boundaries, as GeoJSON or
+	// zipped shapefiles
+	TimeZonesDir = "us/timezones"
+
+	// DefaultNWSAPIURL is the base URL of the NWS public API
+	DefaultNWSAPIURL = "https://api.weather.gov"
+	// DefaultZipCodesURL is where the zip code database is downloaded from
+	DefaultZipCodesURL = "https://www.unitedstateszipcodes.org/zip_code_database.csv"
+	// DefaultZCTAURL is the 2020 Census ZCTA boundary shapefile
+	DefaultZCTAURL = "https://www2.census.gov/geo/tiger/TIGER2020/ZCTA520/tl_2020_us_zcta520.zip"
+	// DefaultTimeZonesURL is the timezone-boundary-builder release of time zone boundaries,
+	// including territorial waters
+	DefaultTimeZonesURL = "https://github.com/evansiroky/timezone-boundary-builder/releases/download/2025b/timezones.shapefile.zip"
+)
+
+var (
+	// NWSZoneTypes are the zone types that are ingested by default
+	NWSZoneTypes = []string{"county", "public", "fire", "coastal", "offshore"}
+
+	// ErrNotFeatureCollection is returned when a zone source is not a GeoJSON FeatureCollection
+	ErrNotFeatureCollection = errors.New("source is not a GeoJSON FeatureCollection")
+)
+
+// NWSZones returns the dataset that downloads each zone type from the NWS API at baseURL and
+// combines their features into the single GeoJSON array read by the migrations. If no types
+// are given, [NWSZoneTypes] is used
+func NWSZones(baseURL string, types ...string) Dataset {
+	if len(types) == 0 {
+		types = NWSZoneTypes
+	}
+
+	urls := make([]string, 0, len(types))
+	for _, t := range types {
+		q := url.Values{}
+		q.Set("type", t)
+		q.Set("include_geometry", "true")
+		urls = append(urls, fmt.Sprintf("%s/zones?%s", baseURL, q.Encode()))
+	}
+
+	return Dataset{
+		Name:    "nws-zones",
+		Path:    NWSZonesPath,
+		URLs:    urls,
+		Combine: ConcatFeatureCollections,
+	}
+}
+
+// ZipCodes returns the dataset for the zip code database CSV
+func ZipCodes(srcURL string) Dataset {
+	return Dataset{
+		Name: "zip-codes",
+		Path: ZipCodesPath,
+		URLs: []string{srcURL},
+	}
+}
+
+// ManualFixes returns one dataset per manual geometry fix. Each name is a file name such as
+// "OHC035.wkt" that is downloaded from baseURL
+func ManualFixes(baseURL string, names ...string) []Dataset {
+	datasets := make([]Dataset, 0, len(names))
+	for _, name := range names {
+		datasets = append(datasets, Dataset{
+			Name: "manual-fix:" + name,
+			Path: path.Join(ManualFixesDir, name),
+			URLs: []string{baseURL + "/" + url.PathEscape(name)},
+		})
+	}
+
+	return datasets
+}
+
+// NWSShapefiles returns one dataset per zipped NWS zone shapefile, such as
+// https://www.weather.gov/source/gis/Shapefiles/County/c_05mr24.zip. Each file keeps its original
+// name, which the migrations use to tell the zone type
+func NWSShapefiles(urls ...string) []Dataset {
+	datasets := make([]Dataset, 0, len(urls))
+	for _, u := range urls {
+		name := path.Base(u)
+		if parsed, err := url.Parse(u); err == nil {
+			name = path.Base(parsed.Path)
+		}
+
+		datasets = append(datasets, Dataset{
+			Name: "nws-shapefile:" + name,
+			Path: path.Join(NWSShapefilesDir, name),
+			URLs: []string{u},
+		})
+	}
+
+	return datasets
+}
+
+// ZCTAShapefile returns the dataset for a zipped Census ZCTA boundary shapefile. It is not one of
+// the [DefaultDatasets], since the national file is several hundred megabytes
+func ZCTAShapefile(srcURL string) Dataset {
+	name := path.Base(srcURL)
+	if parsed, err := url.Parse(srcURL); err == nil {
+		name = path.Base(parsed.Path)
+	}
+
+	return Dataset{
+		Name: "zcta",
+		Path: path.Join(ZCTADir, name),
+		URLs: []string{srcURL},
+	}
+}
+
+// TimeZoneShapefile returns the dataset for a zipped time zone boundary shapefile. It is not one
+// of the [DefaultDatasets], since the file is over a hundred megabytes
+func TimeZoneShapefile(srcURL string) Dataset {
+	name := path.Base(srcURL)
+	if parsed, err := url.Parse(srcURL); err == nil {
+		name = path.Base(parsed.Path)
+	}
+
+	return Dataset{
+		Name: "timezones",
+		Path: path.Join(TimeZonesDir, name),
+		URLs: []string{srcURL},
+	}
+}
+
+// DefaultDatasets returns the NWS zone and zip code datasets from their public sources
+func DefaultDatasets() []Dataset {
+	return []Dataset{
+		NWSZones(DefaultNWSAPIURL),
+		ZipCodes(DefaultZipCodesURL),
+	}
+}
+
+// ConcatFeatureCollections is a [CombineFunc] that streams the features of every source
+// FeatureCollection into a single JSON array, without holding any collection in memory
+func ConcatFeatureCollections(w io.Writer, sources []io.Reader) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	first := true
+	for i, src := range sources {
+		decoder := json.NewDecoder(src)
+		if err := seekFeatures(decoder); err != nil {
+			return fmt.Errorf("source %d: %w", i, err)
+		}
+
+		for decoder.More() {
+			var raw json.RawMessage
+			if err := decoder.Decode(&raw); err != nil {
+				return fmt.Errorf("source %d: %w", i, err)
+			}
+
+			if !first {
+				if _, err := io.WriteString(w, ",\n"); err != nil {
+					return err
+				}
+			}
+			first = false
+
+			if _, err := w.Write(raw); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, "]\n")
+	return err
+}
+
+// seekFeatures advances the decoder to the first element of the top level "features" array
+func seekFeatures(decoder *json.Decoder) error {
+	if tok, err := decoder.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('{') {
+		return ErrNotFeatureCollection
+	}
+
+	for decoder.More() {
+		tok, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		if tok == "features" {
+			if tok, err = decoder.Token(); err != nil {
+				return err
+			} else if tok != json.Delim('[') {
+				return ErrNotFeatureCollection
+			}
+
+			return nil
+		}
+
+		// skip the value of any other member
+		var skip json.RawMessage
+		if err = decoder.Decode(&skip); err != nil {
+			return err
+		}
+	}
+
+	return ErrNotFeatureCollection
+}
@@ -0,0 +1,221 @@
+// Package fetch downloads the source data used by the geodata migrations and writes it into the
+// directory layout expected by [github.com/watchedsky-social/libwatchedsky/geodata/migrations.SourceDataRoot],
+// recording a checksum for every file in a manifest at the root
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/watchedsky-social/libwatchedsky"
+)
+
+// HTTPClient is the subset of [net/http.Client] used to download datasets. Any implementation,
+// such as a client pointed at an [net/http/httptest.Server], can be supplied with [WithHTTPClient]
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Option configures a [Fetcher]
+type Option func(f *Fetcher)
+
+// Fetcher downloads datasets into a source data root
+type Fetcher struct {
+	root      string
+	client    HTTPClient
+	userAgent string
+}
+
+// DefaultUserAgent is sent with every request. The NWS API rejects requests without one
+const DefaultUserAgent = "libwatchedsky-fetch (https://github.com/watchedsky-social/libwatchedsky)"
+
+var (
+	// ErrUnexpectedStatus is returned when a dataset source responds with anything but 200 OK
+	ErrUnexpectedStatus = errors.New("unexpected HTTP status")
+)
+
+// WithHTTPClient sets the client used for downloads. The default is [net/http.DefaultClient]
+func WithHTTPClient(client HTTPClient) Option {
+	return func(f *Fetcher) {
+		f.client = client
+	}
+}
+
+// WithUserAgent overrides [DefaultUserAgent]
+func WithUserAgent(userAgent string) Option {
+	return func(f *Fetcher) {
+		f.userAgent = userAgent
+	}
+}
+
+// New creates a [Fetcher] that writes into root, creating it if it does not exist
+func New(root string, opts ...Option) (*Fetcher, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.MkdirAll(absRoot, 0o755); err != nil {
+		return nil, err
+	}
+
+	f := &Fetcher{
+		root:      absRoot,
+		client:    http.DefaultClient,
+		userAgent: DefaultUserAgent,
+	}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f, nil
+}
+
+// Root returns the absolute path of the source data root
+func (f *Fetcher) Root() string {
+	return f.root
+}
+
+// Fetch downloads each dataset in turn and writes it into the source data root. The manifest is
+// updated after every dataset, so a failure part way through keeps the checksums of the
+// datasets that were already written
+func (f *Fetcher) Fetch(ctx context.Context, datasets ...Dataset) error {
+	if ctx == nil {
+		return libwatchedsky.ErrNilContext
+	}
+
+	manifest, err := ReadManifest(f.root)
+	if err != nil {
+		return err
+	}
+
+	for _, ds := range datasets {
+		entry, err := f.fetchDataset(ctx, ds)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ds.Name, err)
+		}
+
+		manifest.Files[ds.Path] = *entry
+		if err = manifest.Write(f.root); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *Fetcher) fetchDataset(ctx context.Context, ds Dataset) (*ManifestEntry, error) {
+	dest := filepath.Join(f.root, filepath.FromSlash(ds.Path))
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return nil, err
+	}
+
+	// every source is downloaded in full before anything is written to dest, so a failed
+	// request never leaves a truncated file in place of a good one
+	sources := make([]io.Reader, 0, len(ds.URLs))
+	for _, srcURL := range ds.URLs {
+		tmp, err := f.download(ctx, srcURL, filepath.Dir(dest))
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+
+		sources = append(sources, tmp)
+	}
+
+	combine := ds.Combine
+	if combine == nil {
+		combine = concat
+	}
+
+	out, err := os.CreateTemp(filepath.Dir(dest), ".tmp-"+filepath.Base(dest))
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(out.Name())
+
+	h := sha256.New()
+	cw := &countingWriter{w: io.MultiWriter(out, h)}
+	if err = combine(cw, sources); err != nil {
+		out.Close()
+		return nil, err
+	}
+
+	if err = out.Close(); err != nil {
+		return nil, err
+	}
+
+	if err = os.Rename(out.Name(), dest); err != nil {
+		return nil, err
+	}
+
+	return &ManifestEntry{
+		SHA256:    hex.EncodeToString(h.Sum(nil)),
+		Size:      cw.n,
+		Sources:   ds.URLs,
+		FetchedAt: time.Now().UTC(),
+	}, nil
+}
+
+// download saves the body of srcURL to a temporary file in dir, and returns it rewound to the
+// beginning
+func (f *Fetcher) download(ctx context.Context, srcURL, dir string) (*os.File, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srcURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: GET %s: %s", ErrUnexpectedStatus, srcURL, resp.Status)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".download-")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = io.Copy(tmp, resp.Body); err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	return tmp, nil
+}
+
+func concat(w io.Writer, sources []io.Reader) error {
+	_, err := io.Copy(w, io.MultiReader(sources...))
+	return err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
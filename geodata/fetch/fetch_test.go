@@ -0,0 +1,254 @@
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/watchedsky-social/libwatchedsky"
+)
+
+const zipCodesCSV = "zip,type,primary_city\n43215,STANDARD,Columbus\n"
+
+// sourceServer is an httptest stand-in for the NWS API and the other dataset sources
+type sourceServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	failPaths map[string]bool
+	bodies    map[string]string
+	userAgent string
+}
+
+func newSourceServer(t *testing.T) *sourceServer {
+	t.Helper()
+
+	s := &sourceServer{
+		failPaths: map[string]bool{},
+		bodies: map[string]string{
+			"/zip.csv":          zipCodesCSV,
+			"/fixes/OHC035.wkt": "POLYGON ((0 0, 1 0, 1 1, 0 0))",
+		},
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.userAgent = r.UserAgent()
+		if s.failPaths[r.URL.Path] || s.failPaths[r.URL.Path+"?type="+r.URL.Query().Get("type")] {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		if r.URL.Path == "/zones" {
+			t := r.URL.Query().Get("type")
+			w.Write([]byte(`{"type":"FeatureCollection","@context":{"x":[1,2]},"features":[` +
+				`{"type":"Feature","id":"` + t + `1","properties":{"type":"` + t + `"},"geometry":null}]}`))
+			return
+		}
+
+		body, ok := s.bodies[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// fail makes requests for path, which may include a zone type as in "/zones?type=public", respond
+// with an error
+func (s *sourceServer) fail(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failPaths[path] = true
+}
+
+func (s *sourceServer) serve(path, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bodies[path] = body
+}
+
+func (s *sourceServer) datasets() []Dataset {
+	datasets := []Dataset{NWSZones(s.URL, "county", "public"), ZipCodes(s.URL + "/zip.csv")}
+	return append(datasets, ManualFixes(s.URL+"/fixes", "OHC035.wkt")...)
+}
+
+func newTestFetcher(t *testing.T, s *sourceServer) *Fetcher {
+	t.Helper()
+
+	f, err := New(t.TempDir(), WithHTTPClient(s.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
+func readFile(t *testing.T, root, p string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(p)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func TestFetchLayout(t *testing.T) {
+	s := newSourceServer(t)
+	f := newTestFetcher(t, s)
+
+	if err := f.Fetch(context.Background(), s.datasets()...); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.userAgent != DefaultUserAgent {
+		t.Errorf("User-Agent = %q, want %q", s.userAgent, DefaultUserAgent)
+	}
+
+	var features []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(readFile(t, f.Root(), NWSZonesPath)), &features); err != nil {
+		t.Fatalf("%s is not a JSON array: %v", NWSZonesPath, err)
+	}
+
+	if len(features) != 2 || features[0].ID != "county1" || features[1].ID != "public1" {
+		t.Errorf("%s features = %+v, want county1 then public1", NWSZonesPath, features)
+	}
+
+	if got := readFile(t, f.Root(), ZipCodesPath); got != zipCodesCSV {
+		t.Errorf("%s = %q, want %q", ZipCodesPath, got, zipCodesCSV)
+	}
+
+	if got := readFile(t, f.Root(), ManualFixesDir+"/OHC035.wkt"); !strings.HasPrefix(got, "POLYGON") {
+		t.Errorf("manual fix = %q, want the served WKT", got)
+	}
+}
+
+func TestFetchManifestChecksums(t *testing.T) {
+	s := newSourceServer(t)
+	f := newTestFetcher(t, s)
+	datasets := s.datasets()
+
+	if err := f.Fetch(context.Background(), datasets...); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := ReadManifest(f.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Files) != len(datasets) {
+		t.Fatalf("manifest has %d files, want %d", len(m.Files), len(datasets))
+	}
+
+	for _, ds := range datasets {
+		entry, ok := m.Files[ds.Path]
+		if !ok {
+			t.Errorf("manifest is missing %s", ds.Path)
+			continue
+		}
+
+		data := readFile(t, f.Root(), ds.Path)
+		sum := sha256.Sum256([]byte(data))
+		if entry.SHA256 != hex.EncodeToString(sum[:]) || entry.Size != int64(len(data)) {
+			t.Errorf("%s: manifest entry %+v does not match the file on disk", ds.Path, entry)
+		}
+	}
+
+	if err = m.Verify(f.Root()); err != nil {
+		t.Errorf("Verify() = %v, want nil", err)
+	}
+
+	if err = os.WriteFile(filepath.Join(f.Root(), filepath.FromSlash(ZipCodesPath)), []byte("changed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = m.Verify(f.Root()); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Verify() after changing a file = %v, want %v", err, ErrChecksumMismatch)
+	}
+}
+
+func TestFetchIsAtomic(t *testing.T) {
+	s := newSourceServer(t)
+	f := newTestFetcher(t, s)
+	ctx := context.Background()
+
+	if err := f.Fetch(ctx, s.datasets()...); err != nil {
+		t.Fatal(err)
+	}
+
+	before := readFile(t, f.Root(), NWSZonesPath)
+	manifestBefore := readFile(t, f.Root(), ManifestFile)
+
+	// the first zone type downloads, the second fails
+	s.fail("/zones?type=public")
+
+	err := f.Fetch(ctx, NWSZones(s.URL, "county", "public"))
+	if !errors.Is(err, ErrUnexpectedStatus) {
+		t.Fatalf("Fetch() = %v, want %v", err, ErrUnexpectedStatus)
+	}
+
+	if got := readFile(t, f.Root(), NWSZonesPath); got != before {
+		t.Errorf("failed fetch changed %s", NWSZonesPath)
+	}
+
+	if got := readFile(t, f.Root(), ManifestFile); got != manifestBefore {
+		t.Errorf("failed fetch changed the manifest")
+	}
+
+	// a source that downloads but cannot be combined must not replace the file either
+	s.serve("/broken.json", `{"type":"FeatureCollection","features":[{"id":`)
+	broken := Dataset{Name: "broken", Path: NWSZonesPath, URLs: []string{s.URL + "/broken.json"},
+		Combine: ConcatFeatureCollections}
+
+	if err = f.Fetch(ctx, broken); err == nil {
+		t.Fatal("Fetch() of a truncated FeatureCollection = nil, want an error")
+	}
+
+	if got := readFile(t, f.Root(), NWSZonesPath); got != before {
+		t.Errorf("failed combine changed %s", NWSZonesPath)
+	}
+
+	// no temporary downloads or partial files are left behind
+	entries, err := os.ReadDir(filepath.Dir(filepath.Join(f.Root(), filepath.FromSlash(NWSZonesPath))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			t.Errorf("temporary file %s was left behind", e.Name())
+		}
+	}
+}
+
+func TestFetchNilContext(t *testing.T) {
+	s := newSourceServer(t)
+	f := newTestFetcher(t, s)
+
+	if err := f.Fetch(nil, s.datasets()...); !errors.Is(err, libwatchedsky.ErrNilContext) {
+		t.Errorf("Fetch(nil) = %v, want %v", err, libwatchedsky.ErrNilContext)
+	}
+}
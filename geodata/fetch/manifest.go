@@ -0,0 +1,133 @@
+package fetch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ManifestFile is the name of the checksum manifest, relative to the source data root
+const ManifestFile = "manifest.json"
+
+var (
+	// ErrChecksumMismatch is returned by [Manifest.Verify] when a file on disk no longer matches
+	// the checksum recorded when it was fetched
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+// ManifestEntry records where a single source data file came from and what its contents were
+type ManifestEntry struct {
+	SHA256    string    `json:"sha256"`
+	Size      int64     `json:"size"`
+	Sources   []string  `json:"sources"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// Manifest maps slash-separated paths, relative to the source data root, to the checksum of
+// the file that was written there
+type Manifest struct {
+	Files map[string]ManifestEntry `json:"files"`
+}
+
+// ReadManifest loads the manifest from the source data root. A missing manifest is not an
+// error; an empty [Manifest] is returned instead
+func ReadManifest(root string) (*Manifest, error) {
+	m := &Manifest{Files: map[string]ManifestEntry{}}
+
+	data, err := os.ReadFile(filepath.Join(root, ManifestFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return m, nil
+		}
+
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+
+	if m.Files == nil {
+		m.Files = map[string]ManifestEntry{}
+	}
+
+	return m, nil
+}
+
+// Write saves the manifest to the source data root
+func (m *Manifest) Write(root string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath.Join(root, ManifestFile), data)
+}
+
+// Verify checks every file in the manifest against the copy on disk, and returns an error
+// wrapping [ErrChecksumMismatch] for each file that differs
+func (m *Manifest) Verify(root string) error {
+	paths := make([]string, 0, len(m.Files))
+	for p := range m.Files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var errs []error
+	for _, p := range paths {
+		sum, _, err := checksumFile(filepath.Join(root, filepath.FromSlash(p)))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if sum != m.Files[p].SHA256 {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrChecksumMismatch, p))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func checksumFile(file string) (string, int64, error) {
+	fp, err := os.Open(file)
+	if err != nil {
+		return "", 0, err
+	}
+	defer fp.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, fp)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+func writeFileAtomic(file string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".tmp-"+filepath.Base(file))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
@@ -0,0 +1,261 @@
+package geodata
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrInvalidSAME is returned when a SAME location code is not six digits
+	ErrInvalidSAME = errors.New("invalid SAME code")
+
+	// StateFIPS maps US state and territory abbreviations to their two digit FIPS codes
+	StateFIPS = map[string]string{
+		"AL": "01", "AK": "02", "AZ": "04", "AR": "05", "CA": "06", "CO": "08", "CT": "09",
+		"DE": "10", "DC": "11", "FL": "12", "GA": "13", "HI": "15", "ID": "16", "IL": "17",
+		"IN": "18", "IA": "19", "KS": "20", "KY": "21", "LA": "22", "ME": "23", "MD": "24",
+		"MA": "25", "MI": "26", "MN": "27", "MS": "28", "MO": "29", "MT": "30", "NE": "31",
+		"NV": "32", "NH": "33", "NJ": "34", "NM": "35", "NY": "36", "NC": "37", "ND": "38",
+		"OH": "39", "OK": "40", "OR": "41", "PA": "42", "RI": "44", "SC": "45", "SD": "46",
+		"TN": "47", "TX": "48", "UT": "49", "VT": "50", "VA": "51", "WA": "53", "WV": "54",
+		"WI": "55", "WY": "56", "AS": "60", "GU": "66", "MP": "69", "PR": "72", "VI": "78",
+	}
+)
+
+const (
+	selectZoneByFIPS       = `SELECT %s FROM zones WHERE fips = ? AND retired_at IS NULL`
+	selectZonesByStateFIPS = `SELECT %s FROM zones
+WHERE fips LIKE ? || '%%' AND type = 'county' AND retired_at IS NULL ORDER BY fips`
+	selectZoneFIPS   = `SELECT fips FROM zones WHERE oid = ?`
+	updateZoneFIPS   = `UPDATE zones SET fips = ? WHERE oid = ?`
+	selectCountyRows = `SELECT oid, id, type, metadata FROM zones WHERE type = 'county'`
+	// the counties a public, fire or marine zone overlaps carry its SAME codes
+	selectOverlappingFIPS = `SELECT DISTINCT z.fips FROM zone_county_pivot p INNER JOIN zones z ON z.oid = p.county_oid
+WHERE p.zone_oid = ? AND z.fips IS NOT NULL AND z.retired_at IS NULL ORDER BY z.fips`
+)
+
+// SAMECode is a Specific Area Message Encoding location code, PSSCCC, as carried by NOAA Weather
+// Radio and the geocodes of CAP alerts
+type SAMECode struct {
+	// Portion is the part of the county affected (1 to 9 for a ninth of it, clockwise from the
+	// northwest), or 0 for the whole county
+	Portion int
+	// State is the two digit state FIPS code
+	State string
+	// County is the three digit county FIPS code, or 000 for the whole state
+	County string
+}
+
+// ParseSAME parses a six digit SAME code. A leading zero that pads the code to seven digits, as
+// some feeds do, is accepted
+func ParseSAME(code string) (SAMECode, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == 7 && code[0] == '0' {
+		code = code[1:]
+	}
+
+	if len(code) != 6 || strings.Trim(code, "0123456789") != "" {
+		return SAMECode{}, fmt.Errorf("%w: %q", ErrInvalidSAME, code)
+	}
+
+	return SAMECode{Portion: int(code[0] - '0'), State: code[1:3], County: code[3:]}, nil
+}
+
+// FIPS returns the five digit county FIPS code
+func (c SAMECode) FIPS() string {
+	return c.State + c.County
+}
+
+// Statewide reports whether the code covers every county in the state
+func (c SAMECode) Statewide() bool {
+	return c.County == "000"
+}
+
+// String returns the code in PSSCCC form
+func (c SAMECode) String() string {
+	return fmt.Sprintf("%d%s%s", c.Portion, c.State, c.County)
+}
+
+// CountyFIPS returns the five digit FIPS code of a county zone, from its "fips" property if it
+// was ingested from a shapefile, or else from its state and UGC number (OHC035 is 39035). It
+// returns false for zones that are not counties
+func CountyFIPS(z *Zone) (string, bool) {
+	if z.Type != "county" {
+		return "", false
+	}
+
+	if fips := z.Metadata.MustString("fips", ""); len(fips) == 5 {
+		return fips, true
+	}
+
+	id := z.ShortID()
+	if len(id) != 6 || id[2] != 'C' {
+		return "", false
+	}
+
+	state, ok := StateFIPS[strings.ToUpper(id[:2])]
+	if !ok {
+		return "", false
+	}
+
+	return state + id[3:], true
+}
+
+// AssignZoneFIPS sets the fips column of every county zone with [CountyFIPS]
+func AssignZoneFIPS(ctx context.Context, q Querier) error {
+	rows, err := q.QueryContext(ctx, selectCountyRows)
+	if err != nil {
+		return err
+	}
+
+	var counties []*Zone
+	for rows.Next() {
+		var (
+			z        Zone
+			metadata []byte
+		)
+
+		if err = rows.Scan(&z.oid, &z.ID, &z.Type, &metadata); err != nil {
+			rows.Close()
+			return err
+		}
+
+		if metadata != nil {
+			if err = json.Unmarshal(metadata, &z.Metadata); err != nil {
+				rows.Close()
+				return fmt.Errorf("%s: %w", z.oid, err)
+			}
+		}
+
+		counties = append(counties, &z)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, z := range counties {
+		if err = setZoneFIPS(ctx, q, z); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setZoneFIPS sets the fips column of z, which is cleared for anything but a county
+func setZoneFIPS(ctx context.Context, q Querier, z *Zone) error {
+	var fips any
+	if f, ok := CountyFIPS(z); ok {
+		fips = f
+	}
+
+	_, err := q.ExecContext(ctx, updateZoneFIPS, fips, z.OID())
+	return err
+}
+
+// ZoneBySAME returns the active county identified by a SAME code, ignoring the portion of the
+// county, or [ErrZoneNotFound]. Statewide codes match no single county; use [Store.ZonesBySAME]
+func (s *Store) ZoneBySAME(ctx context.Context, code string, opts ...ZoneOption) (*Zone, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	same, err := ParseSAME(code)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newZoneOptions(opts)
+	z, err := scanZone(s.db.QueryRowContext(ctx, fmt.Sprintf(selectZoneByFIPS, zoneColumnsAt(o.resolution)), same.FIPS()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrZoneNotFound
+	}
+
+	return z, err
+}
+
+// ZonesBySAME returns the active counties identified by a SAME code, which is every county in the
+// state for a statewide code
+func (s *Store) ZonesBySAME(ctx context.Context, code string, opts ...ZoneOption) ([]*Zone, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	same, err := ParseSAME(code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !same.Statewide() {
+		z, err := s.ZoneBySAME(ctx, code, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*Zone{z}, nil
+	}
+
+	o := newZoneOptions(opts)
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(selectZonesByStateFIPS, zoneColumnsAt(o.resolution)), same.State)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var zones []*Zone
+	for rows.Next() {
+		z, err := scanZone(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		zones = append(zones, z)
+	}
+
+	return zones, rows.Err()
+}
+
+// SAMECodesForZone returns the whole-county SAME codes that cover a zone: its own code for a
+// county, or the codes of the counties it overlaps for any other zone
+func (s *Store) SAMECodesForZone(ctx context.Context, oid string) ([]string, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	var fips sql.NullString
+	err := s.db.QueryRowContext(ctx, selectZoneFIPS, oid).Scan(&fips)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrZoneNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if fips.Valid {
+		return []string{"0" + fips.String}, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, selectOverlappingFIPS, oid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var codes []string
+	for rows.Next() {
+		if err = rows.Scan(&fips); err != nil {
+			return nil, err
+		}
+
+		codes = append(codes, "0"+fips.String)
+	}
+
+	return codes, rows.Err()
+}
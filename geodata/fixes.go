@@ -0,0 +1,157 @@
+package geodata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkt"
+	"github.com/paulmach/orb/geojson"
+)
+
+var (
+	// ErrInvalidGeometryFix is returned when a manual fix file cannot be read as a geometry
+	ErrInvalidGeometryFix = errors.New("invalid geometry fix")
+	// ErrUnmatchedGeometryFix is returned when a manual fix does not match any zone, which
+	// usually means the zone was renumbered and the fix is stale
+	ErrUnmatchedGeometryFix = errors.New("geometry fix matches no zone")
+	// ErrAmbiguousGeometryFix is returned when a fix keyed by short ID matches more than one zone
+	ErrAmbiguousGeometryFix = errors.New("geometry fix matches more than one zone")
+	// ErrUnmatchedOverride is returned when a metadata patch does not match any zone, which
+	// usually means the zone was renumbered and the patch is stale
+	ErrUnmatchedOverride = errors.New("zone override matches no zone")
+	// ErrAmbiguousOverride is returned when a metadata patch keyed by short ID matches more than
+	// one zone
+	ErrAmbiguousOverride = errors.New("zone override matches more than one zone")
+)
+
+// fixExtensions are the file types a manual geometry fix may be written in
+var fixExtensions = map[string]bool{
+	".wkt":     true,
+	".geojson": true,
+	".json":    true,
+}
+
+// GeometryFix is a hand-corrected geometry that replaces the published geometry of a zone
+type GeometryFix struct {
+	// File is the path the fix was loaded from
+	File string
+	// Key is the file name without its extension, and is either the short ID of a zone (e.g.
+	// OHC035) or its full OID. A short ID is shared by a public and a fire zone in some states,
+	// so an OID key is needed to fix only one of them
+	Key string
+	// Geometry is the replacement geometry
+	Geometry *Geometry
+}
+
+// AppliedGeometryFix records that a fix replaced the geometry of a zone
+type AppliedGeometryFix struct {
+	OID       string    `json:"oid"`
+	File      string    `json:"file"`
+	AppliedAt time.Time `json:"appliedAt"`
+}
+
+const selectAppliedGeometryFixes = `SELECT oid, fix_file, applied_at FROM zone_geometry_fixes ORDER BY oid`
+
+// Matches reports whether the fix applies to z
+func (f *GeometryFix) Matches(z *Zone) bool {
+	return overrideMatches(f.Key, z)
+}
+
+// LoadGeometryFixes reads every .wkt, .geojson and .json file in dir, except metadata patches
+// (see [LoadZoneOverrides]). GeoJSON fixes may be a bare geometry or a Feature. A missing
+// directory has no fixes
+func LoadGeometryFixes(dir string) ([]GeometryFix, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var fixes []GeometryFix
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || !fixExtensions[ext] || isMetadataPatch(entry.Name()) {
+			continue
+		}
+
+		file := filepath.Join(dir, entry.Name())
+		g, err := readFixGeometry(file, ext)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidGeometryFix, file, err)
+		}
+
+		fixes = append(fixes, GeometryFix{
+			File:     file,
+			Key:      strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())),
+			Geometry: FromOrbGeometry(g),
+		})
+	}
+
+	sort.Slice(fixes, func(i, j int) bool {
+		return fixes[i].Key < fixes[j].Key
+	})
+
+	return fixes, nil
+}
+
+func readFixGeometry(file, ext string) (orb.Geometry, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if ext == ".wkt" {
+		return wkt.Unmarshal(strings.TrimSpace(string(data)))
+	}
+
+	if f, ferr := geojson.UnmarshalFeature(data); ferr == nil && f.Geometry != nil {
+		return f.Geometry, nil
+	}
+
+	g, err := geojson.UnmarshalGeometry(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.Geometry() == nil {
+		return nil, errors.New("empty geometry")
+	}
+
+	return g.Geometry(), nil
+}
+
+// AppliedGeometryFixes returns every manual fix that replaced a zone geometry. The geometry each
+// one replaced is kept, so that the fix can be reverted
+func (s *Store) AppliedGeometryFixes(ctx context.Context) ([]AppliedGeometryFix, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, selectAppliedGeometryFixes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fixes []AppliedGeometryFix
+	for rows.Next() {
+		var f AppliedGeometryFix
+		if err = rows.Scan(&f.OID, &f.File, &f.AppliedAt); err != nil {
+			return nil, err
+		}
+
+		fixes = append(fixes, f)
+	}
+
+	return fixes, rows.Err()
+}
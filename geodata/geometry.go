@@ -35,8 +35,7 @@ func (g *Geometry) AsOrbGeometry() orb.Geometry {
 
 // Scan implements [database/sql.Scanner]
 func (g *Geometry) Scan(src any) error {
-	var og orb.Geometry
-	s := wkb.Scanner(og)
+	s := wkb.Scanner(nil)
 
 	if src == nil {
 		return nil
@@ -65,13 +64,17 @@ func (g *Geometry) Scan(src any) error {
 		return errors.New("invalid WKB returned")
 	}
 
-	*g = Geometry{g: og}
+	*g = Geometry{g: s.Geometry}
 
 	return nil
 }
 
-// Value implements [database/sql/driver.Valuer]
+// Value implements [database/sql/driver.Valuer]. A nil Geometry is NULL
 func (g *Geometry) Value() (driver.Value, error) {
+	if g == nil {
+		return nil, nil
+	}
+
 	return wkb.Value(g.g).Value()
 }
 
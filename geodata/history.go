@@ -0,0 +1,76 @@
+package geodata
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+)
+
+const (
+	selectZoneCurrentAsOf = `SELECT ` + zoneColumns + ` FROM zones
+WHERE oid = ?1 AND (valid_from IS NULL OR valid_from <= ?2) AND (retired_at IS NULL OR retired_at > ?2)`
+	selectZoneHistoryAsOf = `SELECT ` + zoneColumns + ` FROM zone_history
+WHERE oid = ?1 AND (valid_from IS NULL OR valid_from <= ?2) AND valid_to > ?2
+ORDER BY valid_to
+LIMIT 1`
+)
+
+// ZoneSnapshot is a complete set of zones as published on a given date
+type ZoneSnapshot struct {
+	AsOf   time.Time
+	Source FeatureReader
+}
+
+// ZoneAsOf returns the zone with the given OID as it was at t, which is either the current
+// version or one kept in zone_history by [Store.UpdateZones]. Zones loaded by the migrations have
+// no start date and are treated as valid for any time before they were first changed.
+// [ErrZoneNotFound] is returned if the zone did not exist at t
+func (s *Store) ZoneAsOf(ctx context.Context, oid string, t time.Time) (*Zone, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	t = t.UTC()
+
+	for _, query := range []string{selectZoneCurrentAsOf, selectZoneHistoryAsOf} {
+		z, err := scanZone(s.db.QueryRowContext(ctx, query, oid, t))
+		if err == nil {
+			return z, nil
+		}
+
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+	}
+
+	return nil, ErrZoneNotFound
+}
+
+// IngestZoneSnapshots builds up zone history from dated source snapshots by applying them with
+// [Store.UpdateZones] from oldest to newest. Every snapshot must be newer than any change
+// already in the DB
+func (s *Store) IngestZoneSnapshots(ctx context.Context, snapshots ...ZoneSnapshot) ([]*ZoneUpdateReport, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	sorted := make([]ZoneSnapshot, len(snapshots))
+	copy(sorted, snapshots)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].AsOf.Before(sorted[j].AsOf)
+	})
+
+	reports := make([]*ZoneUpdateReport, 0, len(sorted))
+	for _, snap := range sorted {
+		report, err := s.UpdateZones(ctx, snap.Source, ZoneUpdateOptions{EffectiveAt: snap.AsOf})
+		if err != nil {
+			return reports, err
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
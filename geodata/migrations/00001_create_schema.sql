@@ -16,10 +16,42 @@ SELECT AddGeometryColumn('zones', 'center', 4326, 'POINT', 'XY');
 SELECT AddGeometryColumn('zones', 'geometry', 4326, 'GEOMETRY', 'XY');
 
 CREATE UNIQUE INDEX ui_zones_id ON zones(id);
+
+-- how each zone was repaired and overridden during ingest, so these exist before 00002
+CREATE TABLE geometry_validation (
+  oid TEXT NOT NULL PRIMARY KEY,
+  valid INTEGER NOT NULL,
+  reason TEXT NOT NULL,
+  area_before REAL NOT NULL,
+  area_after REAL NOT NULL,
+  vertices_before INTEGER NOT NULL,
+  vertices_after INTEGER NOT NULL
+);
+
+CREATE TABLE zone_overrides (
+  oid TEXT NOT NULL,
+  file TEXT NOT NULL,
+  kind TEXT NOT NULL,
+  applied_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (oid, file)
+);
+
+CREATE TABLE zone_geometry_fixes (
+  oid TEXT NOT NULL PRIMARY KEY,
+  fix_file TEXT NOT NULL,
+  applied_at TIMESTAMP NOT NULL,
+  FOREIGN KEY (oid) REFERENCES zones (oid) ON DELETE CASCADE
+);
+
+SELECT AddGeometryColumn('zone_geometry_fixes', 'original_geometry', 4326, 'GEOMETRY', 'XY');
 -- +goose StatementEnd
 
 -- +goose Down
 -- +goose StatementBegin
+SELECT DiscardGeometryColumn('zone_geometry_fixes', 'original_geometry');
+DROP TABLE zone_geometry_fixes;
+DROP TABLE zone_overrides;
+DROP TABLE geometry_validation;
 DROP INDEX ui_zones_id;
 DROP TABLE zones;
 -- +goose StatementEnd
@@ -5,13 +5,15 @@ package migrations
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"time"
 
-	"github.com/paulmach/orb/geojson"
-	"github.com/paulmach/orb/planar"
+	"github.com/jghiloni/go-commonutils/v3/slices"
 	"github.com/pressly/goose/v3"
 	"github.com/watchedsky-social/libwatchedsky/geodata"
 )
@@ -28,51 +30,131 @@ func upAddNwsData(ctx context.Context, tx *sql.Tx) error {
 		return err
 	}
 
-	fp, err := os.Open(filepath.Join(datadir, "us", "nws_zone_geojson", "all.json"))
+	stmt, err := tx.PrepareContext(ctx, insertNwsDataQuery)
 	if err != nil {
 		return err
 	}
-	defer fp.Close()
-
-	decoder := json.NewDecoder(fp)
-	if _, err = decoder.Token(); err != nil {
-		return err
-	}
+	defer stmt.Close()
 
-	stmt, err := tx.PrepareContext(ctx, insertNwsDataQuery)
+	// overrides replace broken geometries and metadata before they reach the DB, so they are
+	// validated like any other zone
+	overrides, err := geodata.LoadZoneOverrides(manualFixesDir(datadir))
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
 
-	for decoder.More() {
-		var f geojson.Feature
-		if err = decoder.Decode(&f); err != nil {
+	threshold := RepairThreshold(ctx)
+	appliedAt := time.Now().UTC()
+	err = readNWSZones(datadir, overrides, func(z *geodata.Zone, applied []geodata.AppliedZoneOverride) error {
+		v, err := geodata.ValidateZoneGeometry(ctx, tx, z)
+		if err != nil {
 			return err
 		}
 
-		centroid, _ := planar.CentroidArea(f.Geometry)
+		if err = v.CheckThreshold(threshold); err != nil {
+			return err
+		}
 
-		z := &geodata.Zone{
-			ID:       fmt.Sprintf("%v", f.ID),
-			Name:     f.Properties.MustString("name", ""),
-			Type:     f.Properties.MustString("type", "public"),
-			Metadata: geodata.JSONB(f.Properties),
-			Center:   geodata.FromOrbGeometry(centroid),
-			Geometry: geodata.FromOrbGeometry(f.Geometry),
+		if _, err = stmt.ExecContext(ctx, z.OID(), z.ID, z.Name, z.Type, z.Center, z.Geometry, z.Metadata); err != nil {
+			return err
 		}
-		z.SetOID("us")
 
-		if _, err = stmt.ExecContext(ctx, z.OID(), z.ID, z.Name, z.Type, z.Center, z.Geometry,
-			z.Metadata); err != nil {
+		if err = geodata.RecordValidationResult(ctx, tx, v); err != nil {
 			return err
 		}
+
+		return geodata.RecordZoneOverrides(ctx, tx, applied, appliedAt)
+	})
+	if err != nil {
+		return err
+	}
+
+	return overrides.Check()
+}
+
+func manualFixesDir(datadir string) string {
+	return filepath.Join(datadir, "us", "nws_zone_geojson", "manual-fixes")
+}
+
+// nwsZoneSources returns the combined NWS zone GeoJSON if there is one, or every zipped zone
+// shapefile otherwise
+func nwsZoneSources(datadir string) ([]string, error) {
+	geojsonFile := filepath.Join(datadir, "us", "nws_zone_geojson", "all.json")
+	if _, err := os.Stat(geojsonFile); err == nil {
+		return []string{geojsonFile}, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	shapefiles, _ := filepath.Glob(filepath.Join(datadir, "us", "nws_zone_shapefiles", "*.zip"))
+
+	// other NWS layers, such as county warning areas, live alongside the zones
+	shapefiles = slices.Filter(shapefiles, func(file string) bool {
+		_, ok := geodata.NWSShapefileZoneType(file)
+		return ok
+	})
+
+	if len(shapefiles) == 0 {
+		return nil, fmt.Errorf("%w: no NWS zone GeoJSON or shapefiles found", ErrInvalidSourceDataRoot)
+	}
+
+	return shapefiles, nil
+}
+
+// readNWSZones builds every zone in the NWS zone sources under datadir, applying overrides, and
+// passes each one to fn with the overrides that were applied to it
+func readNWSZones(datadir string, overrides *geodata.ZoneOverrides,
+	fn func(z *geodata.Zone, applied []geodata.AppliedZoneOverride) error) error {
+	sources, err := nwsZoneSources(datadir)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range sources {
+		if err = readNWSZoneFile(file, overrides, fn); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
 	}
 
 	return nil
 }
 
+func readNWSZoneFile(file string, overrides *geodata.ZoneOverrides,
+	fn func(z *geodata.Zone, applied []geodata.AppliedZoneOverride) error) error {
+	r, err := geodata.OpenNWSZones(file)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for {
+		f, err := r.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		z := geodata.NewZoneFromFeature("us", f)
+		if err = fn(z, overrides.Apply("us", z)); err != nil {
+			return err
+		}
+	}
+}
+
 func downAddNwsData(ctx context.Context, tx *sql.Tx) error {
-	_, err := tx.ExecContext(ctx, "DELETE FROM zones")
-	return err
+	for _, query := range []string{
+		"DELETE FROM zone_geometry_fixes",
+		"DELETE FROM zone_overrides",
+		"DELETE FROM geometry_validation",
+		"DELETE FROM zones",
+	} {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
@@ -1,8 +1,12 @@
+//go:build migrations
+
 package migrations
 
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 
 	"github.com/pressly/goose/v3"
 )
@@ -19,6 +23,9 @@ const (
 func upAddCountiesToZipData(ctx context.Context, tx *sql.Tx) error {
 	// This code is executed when the migration is applied.
 	zips, err := readZipCodes(ctx)
+	if err != nil {
+		return err
+	}
 
 	getCountyStmt, err := tx.PrepareContext(ctx, getCounty)
 	if err != nil {
@@ -35,16 +42,16 @@ func upAddCountiesToZipData(ctx context.Context, tx *sql.Tx) error {
 	for i := range zips {
 		zip := zips[i].code
 
-		countyOIDResult, ferr := getCountyStmt.QueryContext(ctx, zip)
-		if ferr != nil {
-			return ferr
+		var countyOID string
+		err = getCountyStmt.QueryRowContext(ctx, zip).Scan(&countyOID)
+		if errors.Is(err, sql.ErrNoRows) {
+			// zips outside every county are assigned to the nearest one by zip_county_pivot
+			continue
 		}
 
-		var countyOID string
-		if countyOIDResult.Next() {
-			countyOIDResult.Scan(&countyOID)
+		if err != nil {
+			return fmt.Errorf("%s: %w", zip, err)
 		}
-		countyOIDResult.Close()
 
 		if countyOID != "" {
 			if _, err = updateStmt.ExecContext(ctx, countyOID, zip); err != nil {
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE zones ADD COLUMN retired_at TIMESTAMP DEFAULT NULL;
+
+CREATE INDEX i_zones_retired_at ON zones(retired_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX i_zones_retired_at;
+ALTER TABLE zones DROP COLUMN retired_at;
+-- +goose StatementEnd
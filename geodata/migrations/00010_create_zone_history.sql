@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE zones ADD COLUMN valid_from TIMESTAMP DEFAULT NULL;
+
+CREATE TABLE zone_history (
+  history_id INTEGER PRIMARY KEY AUTOINCREMENT,
+  oid TEXT NOT NULL,
+  id TEXT NOT NULL,
+  name TEXT NOT NULL,
+  type TEXT NOT NULL,
+  metadata BLOB DEFAULT NULL,
+  valid_from TIMESTAMP DEFAULT NULL,
+  valid_to TIMESTAMP NOT NULL
+);
+
+SELECT AddGeometryColumn('zone_history', 'center', 4326, 'POINT', 'XY');
+SELECT AddGeometryColumn('zone_history', 'geometry', 4326, 'GEOMETRY', 'XY');
+
+CREATE INDEX i_zone_history_oid_valid_to ON zone_history(oid, valid_to);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX i_zone_history_oid_valid_to;
+SELECT DiscardGeometryColumn('zone_history', 'geometry');
+SELECT DiscardGeometryColumn('zone_history', 'center');
+DROP TABLE zone_history;
+ALTER TABLE zones DROP COLUMN valid_from;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE zip_county_pivot (
+  zip_code CHAR(5) NOT NULL,
+  county_oid TEXT NOT NULL,
+  weight REAL NOT NULL DEFAULT 1,
+  method TEXT NOT NULL,
+  PRIMARY KEY (zip_code, county_oid),
+  FOREIGN KEY (zip_code) REFERENCES us_zip_codes (code) ON DELETE CASCADE,
+  FOREIGN KEY (county_oid) REFERENCES zones (oid) ON DELETE CASCADE
+);
+
+CREATE INDEX i_zip_county_pivot_county_oid ON zip_county_pivot (county_oid);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX i_zip_county_pivot_county_oid;
+DROP TABLE zip_county_pivot;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+//go:build migrations
+
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddZipCounties, downAddZipCounties)
+}
+
+// resetZipCounties sets us_zip_codes.county_oid back to what 00008 assigns: the county containing
+// the zip code center, or NULL for a center outside every county
+const resetZipCounties = `UPDATE us_zip_codes
+SET county_oid = (SELECT oid FROM zones WHERE type = 'county' AND Contains(geometry, us_zip_codes.center) LIMIT 1)`
+
+func upAddZipCounties(ctx context.Context, tx *sql.Tx) error {
+	return geodata.AssignZipCounties(ctx, tx, ZipCountyDistance(ctx))
+}
+
+func downAddZipCounties(ctx context.Context, tx *sql.Tx) error {
+	// AssignZipCounties also rewrote us_zip_codes.county_oid, including the nearest county of
+	// zips that 00008 left NULL
+	for _, query := range []string{resetZipCounties, `DELETE FROM zip_county_pivot`} {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
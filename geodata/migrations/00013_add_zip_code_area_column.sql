@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT AddGeometryColumn('us_zip_codes', 'area', 4326, 'GEOMETRY', 'XY');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT DiscardGeometryColumn('us_zip_codes', 'area');
+ALTER TABLE us_zip_codes DROP COLUMN area;
+-- +goose StatementEnd
@@ -0,0 +1,82 @@
+//go:build migrations
+
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/pressly/goose/v3"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddZipCodeAreas, downAddZipCodeAreas)
+}
+
+const updateZipCodeAreaQuery = `UPDATE us_zip_codes SET area = GeosMakeValid(ST_GeomFromWKB(?, 4326)) WHERE code = ?`
+
+func upAddZipCodeAreas(ctx context.Context, tx *sql.Tx) error {
+	datadir, err := SourceDataRoot(ctx)
+	if err != nil {
+		return err
+	}
+
+	// ZCTA boundaries are optional, since the national file is large. Zip codes without one fall
+	// back to their center point
+	files, err := filepath.Glob(filepath.Join(datadir, "us", "zcta", "*.zip"))
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, updateZipCodeAreaQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, file := range files {
+		if err = insertZipCodeAreas(ctx, stmt, file); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	return geodata.AssignZipCounties(ctx, tx, ZipCountyDistance(ctx))
+}
+
+func insertZipCodeAreas(ctx context.Context, stmt *sql.Stmt, file string) error {
+	r, err := geodata.OpenZCTAShapefile(file)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for {
+		zcta, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// ZCTAs with no matching zip code, such as those for PO box only codes, are ignored
+		if _, err = stmt.ExecContext(ctx, zcta.Geometry, zcta.Code); err != nil {
+			return fmt.Errorf("%s: %w", zcta.Code, err)
+		}
+	}
+}
+
+func downAddZipCodeAreas(ctx context.Context, tx *sql.Tx) error {
+	// without areas, zip codes are assigned to counties by their center again
+	if _, err := tx.ExecContext(ctx, `UPDATE us_zip_codes SET area = NULL`); err != nil {
+		return err
+	}
+
+	return geodata.AssignZipCounties(ctx, tx, ZipCountyDistance(ctx))
+}
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE zip_zone_pivot (
+  zip_code CHAR(5) NOT NULL,
+  zone_oid TEXT NOT NULL,
+  coverage REAL NOT NULL,
+  method TEXT NOT NULL,
+  PRIMARY KEY (zip_code, zone_oid),
+  FOREIGN KEY (zip_code) REFERENCES us_zip_codes (code) ON DELETE CASCADE,
+  FOREIGN KEY (zone_oid) REFERENCES zones (oid) ON DELETE CASCADE
+);
+
+CREATE INDEX i_zip_zone_pivot_zone_oid ON zip_zone_pivot (zone_oid);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX i_zip_zone_pivot_zone_oid;
+DROP TABLE zip_zone_pivot;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+//go:build migrations
+
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddZipZones, downAddZipZones)
+}
+
+func upAddZipZones(ctx context.Context, tx *sql.Tx) error {
+	return geodata.AssignZipZones(ctx, tx, MarineZoneDistance(ctx))
+}
+
+func downAddZipZones(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM zip_zone_pivot`)
+	return err
+}
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE zone_county_pivot ADD COLUMN intersection_area REAL NOT NULL DEFAULT 0;
+ALTER TABLE zone_county_pivot ADD COLUMN zone_coverage REAL NOT NULL DEFAULT 0;
+ALTER TABLE zone_county_pivot ADD COLUMN county_coverage REAL NOT NULL DEFAULT 0;
+
+UPDATE zone_county_pivot
+SET intersection_area = COALESCE((SELECT ST_Area(ST_Intersection(z1.geometry, z2.geometry))
+                                  FROM zones z1, zones z2
+                                  WHERE z1.oid = zone_county_pivot.zone_oid AND z2.oid = zone_county_pivot.county_oid), 0);
+
+-- zones that only share an edge or a point with a county intersect it with no area
+DELETE FROM zone_county_pivot WHERE intersection_area <= 0;
+
+UPDATE zone_county_pivot
+SET zone_coverage   = intersection_area / (SELECT ST_Area(geometry) FROM zones WHERE oid = zone_county_pivot.zone_oid),
+    county_coverage = intersection_area / (SELECT ST_Area(geometry) FROM zones WHERE oid = zone_county_pivot.county_oid);
+
+CREATE INDEX i_zone_county_pivot_county_oid ON zone_county_pivot (county_oid);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX i_zone_county_pivot_county_oid;
+ALTER TABLE zone_county_pivot DROP COLUMN county_coverage;
+ALTER TABLE zone_county_pivot DROP COLUMN zone_coverage;
+ALTER TABLE zone_county_pivot DROP COLUMN intersection_area;
+
+INSERT OR IGNORE INTO zone_county_pivot
+SELECT z1.oid AS zone_oid, z2.oid AS county_oid
+FROM zones z1
+       INNER JOIN zones z2 ON z1.geometry IS NOT NULL AND z2.geometry IS NOT NULL AND
+                              ST_Intersects(z1.geometry, z2.geometry)
+WHERE z1.type != 'county' AND z2.type = 'county';
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE zones ADD COLUMN fips CHAR(5) DEFAULT NULL;
+CREATE INDEX i_zones_fips ON zones (fips);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX i_zones_fips;
+ALTER TABLE zones DROP COLUMN fips;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+//go:build migrations
+
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddZoneFIPS, downAddZoneFIPS)
+}
+
+func upAddZoneFIPS(ctx context.Context, tx *sql.Tx) error {
+	return geodata.AssignZoneFIPS(ctx, tx)
+}
+
+func downAddZoneFIPS(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `UPDATE zones SET fips = NULL`)
+	return err
+}
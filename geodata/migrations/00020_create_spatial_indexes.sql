@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT CreateSpatialIndex('zones', 'geometry');
+SELECT CreateSpatialIndex('us_zip_codes', 'center');
+SELECT CreateSpatialIndex('us_zip_codes', 'area');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT DisableSpatialIndex('us_zip_codes', 'area');
+DROP TABLE idx_us_zip_codes_area;
+SELECT DisableSpatialIndex('us_zip_codes', 'center');
+DROP TABLE idx_us_zip_codes_center;
+SELECT DisableSpatialIndex('zones', 'geometry');
+DROP TABLE idx_zones_geometry;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE vtec_events (
+  event_key TEXT NOT NULL PRIMARY KEY,
+  office TEXT NOT NULL,
+  phenomena TEXT NOT NULL,
+  significance TEXT NOT NULL,
+  etn INTEGER NOT NULL,
+  year INTEGER NOT NULL,
+  begins TIMESTAMP DEFAULT NULL,
+  ends TIMESTAMP DEFAULT NULL,
+  last_action TEXT NOT NULL,
+  first_seen TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE vtec_event_zones (
+  event_key TEXT NOT NULL,
+  zone_oid TEXT NOT NULL,
+  action TEXT NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (event_key, zone_oid),
+  FOREIGN KEY (event_key) REFERENCES vtec_events (event_key) ON DELETE CASCADE,
+  FOREIGN KEY (zone_oid) REFERENCES zones (oid) ON DELETE CASCADE
+);
+
+CREATE INDEX i_vtec_event_zones_zone_oid ON vtec_event_zones (zone_oid);
+CREATE INDEX i_vtec_events_ends ON vtec_events (ends);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE vtec_event_zones;
+DROP TABLE vtec_events;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE offices (
+  oid TEXT NOT NULL PRIMARY KEY,
+  id TEXT NOT NULL UNIQUE,
+  name TEXT NOT NULL,
+  city TEXT NOT NULL,
+  state TEXT NOT NULL,
+  time_zone TEXT NOT NULL,
+  radars TEXT NOT NULL
+);
+
+SELECT AddGeometryColumn('offices', 'center', 4326, 'POINT', 'XY');
+SELECT AddGeometryColumn('offices', 'geometry', 4326, 'GEOMETRY', 'XY');
+SELECT CreateSpatialIndex('offices', 'geometry');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT DisableSpatialIndex('offices', 'geometry');
+DROP TABLE idx_offices_geometry;
+SELECT DiscardGeometryColumn('offices', 'geometry');
+SELECT DiscardGeometryColumn('offices', 'center');
+DROP TABLE offices;
+-- +goose StatementEnd
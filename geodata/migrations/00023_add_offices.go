@@ -0,0 +1,65 @@
+//go:build migrations
+
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jghiloni/go-commonutils/v3/slices"
+	"github.com/pressly/goose/v3"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddOffices, downAddOffices)
+}
+
+func upAddOffices(ctx context.Context, tx *sql.Tx) error {
+	datadir, err := SourceDataRoot(ctx)
+	if err != nil {
+		return err
+	}
+
+	// CWA shapefiles are published alongside the zone shapefiles, and are optional
+	shapefiles, _ := filepath.Glob(filepath.Join(datadir, "us", "nws_zone_shapefiles", "*.zip"))
+	shapefiles = slices.Filter(shapefiles, geodata.IsCWAShapefile)
+
+	metadata, err := geodata.LoadOfficeMetadata(filepath.Join(datadir, "us", "nws_offices.json"))
+	if err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for _, file := range shapefiles {
+		offices, err := geodata.ReadCWAShapefile(file)
+		if err != nil {
+			return err
+		}
+
+		for _, o := range offices {
+			if seen[o.ID] {
+				return fmt.Errorf("%s: office %s is in more than one CWA shapefile", file, o.ID)
+			}
+			seen[o.ID] = true
+
+			if m, ok := metadata[strings.ToUpper(o.ID)]; ok {
+				o.Apply(m)
+			}
+
+			if err = geodata.InsertOffice(ctx, tx, o); err != nil {
+				return fmt.Errorf("%s: %s: %w", file, o.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func downAddOffices(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM offices`)
+	return err
+}
@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE time_zones (
+  tzid TEXT NOT NULL PRIMARY KEY
+);
+
+SELECT AddGeometryColumn('time_zones', 'geometry', 4326, 'GEOMETRY', 'XY');
+SELECT CreateSpatialIndex('time_zones', 'geometry');
+
+CREATE TABLE zone_time_zones (
+  zone_oid TEXT NOT NULL,
+  tzid TEXT NOT NULL,
+  coverage REAL NOT NULL,
+  PRIMARY KEY (zone_oid, tzid),
+  FOREIGN KEY (zone_oid) REFERENCES zones (oid) ON DELETE CASCADE,
+  FOREIGN KEY (tzid) REFERENCES time_zones (tzid) ON DELETE CASCADE
+);
+
+CREATE INDEX i_zone_time_zones_tzid ON zone_time_zones (tzid);
+
+CREATE TABLE zip_time_zones (
+  zip_code CHAR(5) NOT NULL,
+  tzid TEXT NOT NULL,
+  coverage REAL NOT NULL,
+  PRIMARY KEY (zip_code, tzid),
+  FOREIGN KEY (zip_code) REFERENCES us_zip_codes (code) ON DELETE CASCADE,
+  FOREIGN KEY (tzid) REFERENCES time_zones (tzid) ON DELETE CASCADE
+);
+
+CREATE INDEX i_zip_time_zones_tzid ON zip_time_zones (tzid);
+
+ALTER TABLE zones ADD COLUMN tz TEXT DEFAULT NULL;
+ALTER TABLE us_zip_codes ADD COLUMN tz TEXT DEFAULT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE us_zip_codes DROP COLUMN tz;
+ALTER TABLE zones DROP COLUMN tz;
+DROP TABLE zip_time_zones;
+DROP TABLE zone_time_zones;
+SELECT DisableSpatialIndex('time_zones', 'geometry');
+DROP TABLE idx_time_zones_geometry;
+SELECT DiscardGeometryColumn('time_zones', 'geometry');
+DROP TABLE time_zones;
+-- +goose StatementEnd
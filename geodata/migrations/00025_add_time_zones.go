@@ -0,0 +1,73 @@
+//go:build migrations
+
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pressly/goose/v3"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddTimeZones, downAddTimeZones)
+}
+
+func upAddTimeZones(ctx context.Context, tx *sql.Tx) error {
+	datadir, err := SourceDataRoot(ctx)
+	if err != nil {
+		return err
+	}
+
+	// time zone boundaries are optional, like the ZCTA boundaries. Without them, nothing has a
+	// time zone
+	files, err := filepath.Glob(filepath.Join(datadir, "us", "timezones", "*"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".zip", ".json", ".geojson":
+		default:
+			continue
+		}
+
+		if err = insertTimeZones(ctx, tx, file); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	return geodata.AssignTimeZones(ctx, tx)
+}
+
+func insertTimeZones(ctx context.Context, tx *sql.Tx, file string) error {
+	r, err := geodata.OpenTimeZones(file)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	_, err = geodata.InsertTimeZones(ctx, tx, r)
+	return err
+}
+
+func downAddTimeZones(ctx context.Context, tx *sql.Tx) error {
+	for _, query := range []string{
+		`UPDATE us_zip_codes SET tz = NULL`,
+		`UPDATE zones SET tz = NULL`,
+		`DELETE FROM zip_time_zones`,
+		`DELETE FROM zone_time_zones`,
+		`DELETE FROM time_zones`,
+	} {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT AddGeometryColumn('zones', 'geometry_10m', 4326, 'GEOMETRY', 'XY');
+SELECT AddGeometryColumn('zones', 'geometry_100m', 4326, 'GEOMETRY', 'XY');
+SELECT AddGeometryColumn('zones', 'geometry_1km', 4326, 'GEOMETRY', 'XY');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT DiscardGeometryColumn('zones', 'geometry_1km');
+ALTER TABLE zones DROP COLUMN geometry_1km;
+SELECT DiscardGeometryColumn('zones', 'geometry_100m');
+ALTER TABLE zones DROP COLUMN geometry_100m;
+SELECT DiscardGeometryColumn('zones', 'geometry_10m');
+ALTER TABLE zones DROP COLUMN geometry_10m;
+-- +goose StatementEnd
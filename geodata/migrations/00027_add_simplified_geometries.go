@@ -0,0 +1,24 @@
+//go:build migrations
+
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddSimplifiedGeometries, downAddSimplifiedGeometries)
+}
+
+func upAddSimplifiedGeometries(ctx context.Context, tx *sql.Tx) error {
+	return geodata.SimplifyZoneGeometries(ctx, tx)
+}
+
+func downAddSimplifiedGeometries(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `UPDATE zones SET geometry_10m = NULL, geometry_100m = NULL, geometry_1km = NULL`)
+	return err
+}
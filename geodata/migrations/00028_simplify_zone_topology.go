@@ -0,0 +1,26 @@
+//go:build migrations
+
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+	"github.com/watchedsky-social/libwatchedsky/topology"
+)
+
+func init() {
+	goose.AddMigrationContext(upSimplifyZoneTopology, downSimplifyZoneTopology)
+}
+
+// upSimplifyZoneTopology replaces the per-zone simplified geometries with ones that share their
+// simplified boundaries with their neighbors
+func upSimplifyZoneTopology(ctx context.Context, tx *sql.Tx) error {
+	return topology.SimplifyZoneGeometries(ctx, tx)
+}
+
+func downSimplifyZoneTopology(ctx context.Context, tx *sql.Tx) error {
+	return geodata.SimplifyZoneGeometries(ctx, tx)
+}
@@ -10,12 +10,25 @@ import (
 	"path/filepath"
 
 	"github.com/watchedsky-social/libwatchedsky"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
 )
 
 type migrationContextKey struct{}
 
+type repairThresholdContextKey struct{}
+
+type zipCountyDistanceContextKey struct{}
+
+type marineZoneDistanceContextKey struct{}
+
 var sdrKey migrationContextKey
 
+var rtKey repairThresholdContextKey
+
+var zcdKey zipCountyDistanceContextKey
+
+var mzdKey marineZoneDistanceContextKey
+
 var ErrInvalidSourceDataRoot = errors.New("invalid source data root dir")
 
 // SetSourceDataRoot sets the directory on the current context and returns a new
@@ -60,3 +73,84 @@ func validateSourceRoot(dir string) (string, error) {
 
 	return filepath.Abs(dir)
 }
+
+// SetRepairThreshold sets the largest relative change in area that repairing an invalid zone
+// geometry may cause during ingest, and returns a new [context.Context] that can be passed to
+// [RepairThreshold]
+func SetRepairThreshold(ctx context.Context, threshold float64) (context.Context, error) {
+	if ctx == nil {
+		return nil, libwatchedsky.ErrNilContext
+	}
+
+	if threshold <= 0 {
+		return nil, fmt.Errorf("repair threshold must be positive, got %v", threshold)
+	}
+
+	return context.WithValue(ctx, rtKey, threshold), nil
+}
+
+// RepairThreshold returns the threshold set with [SetRepairThreshold], or
+// [geodata.DefaultRepairThreshold] if none was set
+func RepairThreshold(ctx context.Context) float64 {
+	if ctx != nil {
+		if threshold, ok := ctx.Value(rtKey).(float64); ok {
+			return threshold
+		}
+	}
+
+	return geodata.DefaultRepairThreshold
+}
+
+// SetZipCountyDistance sets how far, in meters, a zip code center may lie outside every county and
+// still be assigned to the nearest one, and returns a new [context.Context] that can be passed to
+// [ZipCountyDistance]
+func SetZipCountyDistance(ctx context.Context, meters float64) (context.Context, error) {
+	if ctx == nil {
+		return nil, libwatchedsky.ErrNilContext
+	}
+
+	if meters <= 0 {
+		return nil, fmt.Errorf("zip county distance must be positive, got %v", meters)
+	}
+
+	return context.WithValue(ctx, zcdKey, meters), nil
+}
+
+// ZipCountyDistance returns the distance set with [SetZipCountyDistance], or
+// [geodata.DefaultZipCountyDistance] if none was set
+func ZipCountyDistance(ctx context.Context) float64 {
+	if ctx != nil {
+		if meters, ok := ctx.Value(zcdKey).(float64); ok {
+			return meters
+		}
+	}
+
+	return geodata.DefaultZipCountyDistance
+}
+
+// SetMarineZoneDistance sets how far, in meters, a coastal marine zone may be from a zip code and
+// still be related to it, and returns a new [context.Context] that can be passed to
+// [MarineZoneDistance]
+func SetMarineZoneDistance(ctx context.Context, meters float64) (context.Context, error) {
+	if ctx == nil {
+		return nil, libwatchedsky.ErrNilContext
+	}
+
+	if meters <= 0 {
+		return nil, fmt.Errorf("marine zone distance must be positive, got %v", meters)
+	}
+
+	return context.WithValue(ctx, mzdKey, meters), nil
+}
+
+// MarineZoneDistance returns the distance set with [SetMarineZoneDistance], or
+// [geodata.DefaultMarineZoneDistance] if none was set
+func MarineZoneDistance(ctx context.Context) float64 {
+	if ctx != nil {
+		if meters, ok := ctx.Value(mzdKey).(float64); ok {
+			return meters
+		}
+	}
+
+	return geodata.DefaultMarineZoneDistance
+}
@@ -0,0 +1,202 @@
+package geodata
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/watchedsky-social/libwatchedsky/geodata/shapefile"
+)
+
+// NWSAPIZoneURL is the prefix of the feature IDs the NWS API assigns to zones. Features read from
+// shapefiles are given IDs with the same prefix, so that a zone has the same ID whichever format
+// it was ingested from
+const NWSAPIZoneURL = "https://api.weather.gov/zones"
+
+var (
+	// ErrUnknownNWSShapefile is returned for shapefiles whose name does not identify a zone type
+	ErrUnknownNWSShapefile = errors.New("unrecognized NWS shapefile")
+
+	// nwsShapefilePrefixes maps the file name prefixes used by NWS GIS releases, e.g.
+	// c_05mr24.zip, to the zone types used in zone metadata
+	nwsShapefilePrefixes = map[string]string{
+		"c_": "county",
+		"z_": "public",
+		"fz": "fire",
+		"mz": "coastal",
+		"oz": "offshore",
+	}
+
+	// nwsAPIZonePaths maps zone types to the path segment used for them in NWS API IDs
+	nwsAPIZonePaths = map[string]string{
+		"county":   "county",
+		"public":   "forecast",
+		"fire":     "fire",
+		"coastal":  "coastal",
+		"offshore": "offshore",
+	}
+)
+
+// NWSShapefileZoneType returns the zone type ("county", "public", "fire", "coastal" or
+// "offshore") of an NWS zone shapefile, based on its file name
+func NWSShapefileZoneType(file string) (string, bool) {
+	base := strings.ToLower(filepath.Base(file))
+	if len(base) < 2 {
+		return "", false
+	}
+
+	t, ok := nwsShapefilePrefixes[base[:2]]
+	return t, ok
+}
+
+// NWSAPIZoneID returns the ID the NWS API assigns to the zone with the given type and short ID,
+// e.g. https://api.weather.gov/zones/county/OHC035
+func NWSAPIZoneID(zoneType, shortID string) string {
+	return fmt.Sprintf("%s/%s/%s", NWSAPIZoneURL, nwsAPIZonePaths[zoneType], shortID)
+}
+
+// ReadNWSShapefile reads a zipped NWS zone shapefile and returns features with the same IDs and
+// property names as the NWS API. NWS shapefiles split some zones into several records (counties
+// are split along forecast office boundaries, for example), so records with the same zone ID are
+// merged into a single feature with a MultiPolygon geometry
+func ReadNWSShapefile(file string) ([]*geojson.Feature, error) {
+	zoneType, ok := NWSShapefileZoneType(file)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownNWSShapefile, file)
+	}
+
+	r, err := shapefile.OpenZip(file)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var features []*geojson.Feature
+	byID := map[string]*geojson.Feature{}
+
+	for {
+		rec, err := r.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		if rec.Geometry == nil {
+			continue
+		}
+
+		f := nwsFeatureFromRecord(zoneType, rec)
+		if f == nil {
+			continue
+		}
+
+		if existing, ok := byID[f.ID.(string)]; ok {
+			mergeNWSFeatures(existing, f)
+			continue
+		}
+
+		byID[f.ID.(string)] = f
+		features = append(features, f)
+	}
+
+	return features, nil
+}
+
+func nwsFeatureFromRecord(zoneType string, rec *geojson.Feature) *geojson.Feature {
+	attrs := rec.Properties
+	state := attrs.MustString("STATE", "")
+
+	var id, name string
+	switch zoneType {
+	case "county":
+		fips := attrs.MustString("FIPS", "")
+		if len(fips) != 5 {
+			return nil
+		}
+		id = state + "C" + fips[2:]
+		name = attrs.MustString("COUNTYNAME", "")
+	case "public", "fire":
+		id = state + "Z" + attrs.MustString("ZONE", "")
+		name = attrs.MustString("NAME", "")
+	default:
+		id = attrs.MustString("ID", "")
+		name = attrs.MustString("NAME", "")
+	}
+
+	if strings.TrimSpace(id) == "" {
+		return nil
+	}
+
+	props := geojson.Properties{
+		"id":   id,
+		"type": zoneType,
+		"name": name,
+	}
+
+	if state != "" {
+		props["state"] = state
+	}
+
+	cwa := attrs.MustString("CWA", attrs.MustString("WFO", ""))
+	if cwa != "" {
+		props["cwa"] = []string{cwa}
+	}
+
+	if fips := attrs.MustString("FIPS", ""); fips != "" {
+		props["fips"] = fips
+	}
+
+	// keep the original attributes for anything that doesn't have an NWS API equivalent
+	props["shapefile"] = map[string]any(attrs)
+
+	f := geojson.NewFeature(rec.Geometry)
+	f.ID = NWSAPIZoneID(zoneType, id)
+	f.Properties = props
+
+	return f
+}
+
+// mergeNWSFeatures folds the geometry and forecast offices of src into dst
+func mergeNWSFeatures(dst, src *geojson.Feature) {
+	dst.Geometry = appendPolygons(toMultiPolygon(dst.Geometry), src.Geometry)
+
+	cwas, _ := dst.Properties["cwa"].([]string)
+	if more, ok := src.Properties["cwa"].([]string); ok {
+		for _, c := range more {
+			found := false
+			for _, existing := range cwas {
+				found = found || existing == c
+			}
+
+			if !found {
+				cwas = append(cwas, c)
+			}
+		}
+	}
+
+	if len(cwas) > 0 {
+		dst.Properties["cwa"] = cwas
+	}
+}
+
+func toMultiPolygon(g orb.Geometry) orb.MultiPolygon {
+	return appendPolygons(nil, g)
+}
+
+func appendPolygons(mp orb.MultiPolygon, g orb.Geometry) orb.MultiPolygon {
+	switch t := g.(type) {
+	case orb.Polygon:
+		return append(mp, t)
+	case orb.MultiPolygon:
+		return append(mp, t...)
+	}
+
+	return mp
+}
@@ -0,0 +1,322 @@
+package geodata
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/planar"
+	"github.com/watchedsky-social/libwatchedsky/geodata/shapefile"
+)
+
+const (
+	officeColumns = `oid, id, name, city, state, time_zone, radars, ST_AsBinary(center), ST_AsBinary(geometry)`
+
+	insertOfficeQuery = `INSERT INTO offices (oid, id, name, city, state, time_zone, radars, center, geometry)
+VALUES (?, ?, ?, ?, ?, ?, ?, ST_GeomFromWKB(?, 4326), GeosMakeValid(ST_GeomFromWKB(?, 4326)))`
+	selectOffice         = `SELECT ` + officeColumns + ` FROM offices WHERE id = ?`
+	selectOffices        = `SELECT ` + officeColumns + ` FROM offices ORDER BY id`
+	selectOfficeForPoint = `SELECT ` + officeColumns + ` FROM offices
+WHERE ROWID IN (SELECT ROWID FROM SpatialIndex
+                WHERE f_table_name = 'offices' AND f_geometry_column = 'geometry' AND search_frame = MakePoint(?1, ?2, 4326))
+  AND ST_Contains(geometry, MakePoint(?1, ?2, 4326))
+LIMIT 1`
+	// NWS API zones list their offices in "cwa", and so do zones read from shapefiles
+	selectZonesForOffice = `SELECT z.oid FROM zones z, json_each(json_extract(CAST(z.metadata AS TEXT), '$.cwa')) cwa
+WHERE cwa.value = ? AND z.retired_at IS NULL
+ORDER BY z.oid`
+)
+
+var (
+	// ErrOfficeNotFound is returned when no office matches a lookup
+	ErrOfficeNotFound = errors.New("office not found")
+)
+
+// Office is an NWS Weather Forecast Office and its County Warning Area (CWA)
+type Office struct {
+	oid string
+	// ID is the three letter office ID, e.g. CLE
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	City     string   `json:"city"`
+	State    string   `json:"state"`
+	TimeZone string   `json:"timeZone"`
+	Radars   []string `json:"radars"`
+	// Center is the location of the office, or the centroid of its CWA if that is unknown
+	Center *Geometry `json:"-"`
+	// Geometry is the County Warning Area
+	Geometry *Geometry `json:"-"`
+}
+
+// OID returns the watchedsky Object ID of the office, e.g. oid:ws:us:oh:cwa:CLE
+func (o *Office) OID() string {
+	if o.oid == "" {
+		return fmt.Sprintf(oidTemplate, "us", strings.ToLower(o.State), "cwa", o.ID)
+	}
+
+	return o.oid
+}
+
+// OfficeMetadata is the information about an office that is not in the CWA shapefile. It is read
+// from a JSON array by [LoadOfficeMetadata]
+type OfficeMetadata struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	City     string   `json:"city"`
+	State    string   `json:"state"`
+	TimeZone string   `json:"timeZone"`
+	Radars   []string `json:"radarStations"`
+}
+
+// LoadOfficeMetadata reads a JSON array of [OfficeMetadata], keyed by office ID. A missing file
+// has no metadata
+func LoadOfficeMetadata(file string) (map[string]OfficeMetadata, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string]OfficeMetadata{}, nil
+		}
+
+		return nil, err
+	}
+
+	var list []OfficeMetadata
+	if err = json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	byID := make(map[string]OfficeMetadata, len(list))
+	for _, m := range list {
+		byID[strings.ToUpper(m.ID)] = m
+	}
+
+	return byID, nil
+}
+
+// Apply fills in the office from m. Fields that m leaves empty keep the values from the shapefile
+func (o *Office) Apply(m OfficeMetadata) {
+	for _, f := range []struct {
+		dst *string
+		src string
+	}{
+		{&o.Name, m.Name},
+		{&o.City, m.City},
+		{&o.State, m.State},
+		{&o.TimeZone, m.TimeZone},
+	} {
+		if f.src != "" {
+			*f.dst = f.src
+		}
+	}
+
+	if len(m.Radars) > 0 {
+		o.Radars = m.Radars
+	}
+}
+
+// IsCWAShapefile reports whether file is an NWS County Warning Area shapefile, such as
+// w_05mr24.zip
+func IsCWAShapefile(file string) bool {
+	return strings.HasPrefix(strings.ToLower(filepath.Base(file)), "w_")
+}
+
+// ReadCWAShapefile reads a zipped NWS County Warning Area shapefile. Offices whose CWA is split
+// into several records are merged into one with a MultiPolygon geometry, and each office is
+// centered on its LON and LAT attributes
+func ReadCWAShapefile(file string) ([]*Office, error) {
+	r, err := shapefile.OpenZip(file)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var offices []*Office
+	byID := map[string]*Office{}
+	geometries := map[string]orb.MultiPolygon{}
+
+	for {
+		rec, err := r.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		attrs := rec.Properties
+		id := strings.TrimSpace(attrs.MustString("CWA", attrs.MustString("WFO", "")))
+		if id == "" || rec.Geometry == nil {
+			continue
+		}
+
+		geometries[id] = appendPolygons(geometries[id], rec.Geometry)
+		if _, ok := byID[id]; ok {
+			continue
+		}
+
+		o := &Office{
+			ID:     id,
+			Name:   attrs.MustString("CITYSTATE", ""),
+			City:   attrs.MustString("CITY", ""),
+			State:  cwaState(attrs),
+			Radars: []string{},
+		}
+
+		lon, lat := attrs.MustFloat64("LON", 0), attrs.MustFloat64("LAT", 0)
+		if lon != 0 || lat != 0 {
+			o.Center = FromOrbGeometry(orb.Point{lon, lat})
+		}
+
+		byID[id] = o
+		offices = append(offices, o)
+	}
+
+	for _, o := range offices {
+		g := geometries[o.ID]
+		o.Geometry = FromOrbGeometry(g)
+		if o.Center == nil {
+			centroid, _ := planar.CentroidArea(g)
+			o.Center = FromOrbGeometry(centroid)
+		}
+	}
+
+	return offices, nil
+}
+
+// cwaState returns the postal code of the state an office is in. CWA shapefiles keep it in ST,
+// and the full state name in STATE, so STATE is only used if it holds a postal code
+func cwaState(attrs geojson.Properties) string {
+	if st := strings.TrimSpace(attrs.MustString("ST", "")); st != "" {
+		return strings.ToUpper(st)
+	}
+
+	if state := strings.TrimSpace(attrs.MustString("STATE", "")); len(state) == 2 {
+		return strings.ToUpper(state)
+	}
+
+	return ""
+}
+
+// InsertOffice inserts an office into the offices table
+func InsertOffice(ctx context.Context, q Querier, o *Office) error {
+	radars, err := json.Marshal(o.Radars)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx, insertOfficeQuery, o.OID(), o.ID, o.Name, o.City, o.State, o.TimeZone,
+		string(radars), o.Center, o.Geometry)
+	return err
+}
+
+// Office returns the office with the given three letter ID, or [ErrOfficeNotFound]
+func (s *Store) Office(ctx context.Context, id string) (*Office, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	o, err := scanOffice(s.db.QueryRowContext(ctx, selectOffice, strings.ToUpper(id)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOfficeNotFound
+	}
+
+	return o, err
+}
+
+// Offices returns every office, ordered by ID
+func (s *Store) Offices(ctx context.Context) ([]*Office, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, selectOffices)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var offices []*Office
+	for rows.Next() {
+		o, err := scanOffice(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		offices = append(offices, o)
+	}
+
+	return offices, rows.Err()
+}
+
+// OfficeForPoint returns the office whose County Warning Area contains the point, or
+// [ErrOfficeNotFound]
+func (s *Store) OfficeForPoint(ctx context.Context, lon, lat float64) (*Office, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	o, err := scanOffice(s.db.QueryRowContext(ctx, selectOfficeForPoint, lon, lat))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOfficeNotFound
+	}
+
+	return o, err
+}
+
+// ZonesForOffice returns the OIDs of the active zones the office is responsible for
+func (s *Store) ZonesForOffice(ctx context.Context, id string) ([]string, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, selectZonesForOffice, strings.ToUpper(id))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var oids []string
+	for rows.Next() {
+		var oid string
+		if err = rows.Scan(&oid); err != nil {
+			return nil, err
+		}
+
+		oids = append(oids, oid)
+	}
+
+	return oids, rows.Err()
+}
+
+// scanOffice scans a row selected with officeColumns
+func scanOffice(row scanner) (*Office, error) {
+	var (
+		o        Office
+		radars   string
+		center   Geometry
+		geometry Geometry
+	)
+
+	if err := row.Scan(&o.oid, &o.ID, &o.Name, &o.City, &o.State, &o.TimeZone, &radars, &center,
+		&geometry); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(radars), &o.Radars); err != nil {
+		return nil, err
+	}
+
+	o.Center = FromOrbGeometry(center.g)
+	o.Geometry = FromOrbGeometry(geometry.g)
+
+	return &o, nil
+}
@@ -0,0 +1,302 @@
+package geodata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/paulmach/orb/planar"
+)
+
+// metadataPatchSuffix marks a file in the overrides directory as a metadata patch rather than a
+// replacement geometry
+const metadataPatchSuffix = ".meta.json"
+
+// The kinds of override recorded in the zone_overrides table
+const (
+	OverrideGeometry = "geometry"
+	OverrideMetadata = "metadata"
+)
+
+const (
+	deleteZoneOverrides = `DELETE FROM zone_overrides WHERE oid = ?`
+	insertZoneOverride  = `INSERT OR REPLACE INTO zone_overrides (oid, file, kind, applied_at) VALUES (?, ?, ?, ?)`
+	selectZoneOverrides = `SELECT oid, file, kind, applied_at FROM zone_overrides ORDER BY oid, file`
+
+	// the published geometry is kept the way ingest would have stored it, so restoring it undoes
+	// the fix exactly
+	deleteGeometryFix = `DELETE FROM zone_geometry_fixes WHERE oid = ?`
+	insertGeometryFix = `INSERT OR REPLACE INTO zone_geometry_fixes (oid, fix_file, applied_at, original_geometry)
+VALUES (?, ?, ?, GeosMakeValid(ST_GeomFromWKB(?, 4326)))`
+)
+
+// MetadataPatch is a JSON merge patch (RFC 7396) applied to the metadata of a zone. The patched
+// "name" and "type" properties become the name and type of the zone
+type MetadataPatch struct {
+	// File is the path the patch was loaded from
+	File string
+	// Key is the short ID or OID of the zone, as with [GeometryFix]
+	Key string
+	// Patch is merged into the zone metadata. Null values remove properties
+	Patch map[string]any
+}
+
+// Matches reports whether the patch applies to z
+func (p *MetadataPatch) Matches(z *Zone) bool {
+	return overrideMatches(p.Key, z)
+}
+
+// AppliedZoneOverride records that an override changed a zone during ingest
+type AppliedZoneOverride struct {
+	OID       string    `json:"oid"`
+	File      string    `json:"file"`
+	Kind      string    `json:"kind"`
+	AppliedAt time.Time `json:"appliedAt"`
+	// Original is the published geometry that a geometry override replaced. It is only set by
+	// [ZoneOverrides.Apply]
+	Original *Geometry `json:"-"`
+}
+
+// ZoneOverrides is a set of per-zone replacement geometries and metadata patches that is consulted
+// while building each [Zone], so that a broken published geometry never reaches the DB
+type ZoneOverrides struct {
+	Geometries []GeometryFix
+	Patches    []MetadataPatch
+
+	// matched maps each override file to the OIDs it was applied to
+	matched map[string]map[string]bool
+}
+
+// LoadZoneOverrides reads the overrides in dir. Files named <key>.meta.json are metadata patches,
+// and any other .wkt, .geojson or .json file is a replacement geometry (see
+// [LoadGeometryFixes]). A missing directory has no overrides
+func LoadZoneOverrides(dir string) (*ZoneOverrides, error) {
+	fixes, err := LoadGeometryFixes(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &ZoneOverrides{Geometries: fixes}
+
+	patches, _ := filepath.Glob(filepath.Join(dir, "*"+metadataPatchSuffix))
+	sort.Strings(patches)
+
+	for _, file := range patches {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		p := MetadataPatch{
+			File: file,
+			Key:  strings.TrimSuffix(filepath.Base(file), metadataPatchSuffix),
+		}
+
+		if err = json.Unmarshal(data, &p.Patch); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		o.Patches = append(o.Patches, p)
+	}
+
+	return o, nil
+}
+
+// Apply patches the metadata and replaces the geometry of z with any overrides that match it,
+// recomputing its name, type, OID and center, and returns the overrides that were applied.
+// Overrides are matched against the zone as published. A nil [ZoneOverrides] applies nothing
+func (o *ZoneOverrides) Apply(country string, z *Zone) []AppliedZoneOverride {
+	if o == nil {
+		return nil
+	}
+
+	var patches []*MetadataPatch
+	for i := range o.Patches {
+		if o.Patches[i].Matches(z) {
+			patches = append(patches, &o.Patches[i])
+		}
+	}
+
+	var fix *GeometryFix
+	for i := range o.Geometries {
+		if o.Geometries[i].Matches(z) {
+			fix = &o.Geometries[i]
+			break
+		}
+	}
+
+	var applied []AppliedZoneOverride
+	for _, p := range patches {
+		if z.Metadata == nil {
+			z.Metadata = JSONB{}
+		}
+
+		mergePatch(map[string]any(z.Metadata), p.Patch)
+		applied = append(applied, AppliedZoneOverride{File: p.File, Kind: OverrideMetadata})
+	}
+
+	if len(patches) > 0 {
+		z.Name = z.Metadata.MustString("name", z.Name)
+		z.Type = z.Metadata.MustString("type", z.Type)
+		z.SetOID(country)
+	}
+
+	if fix != nil {
+		centroid, _ := planar.CentroidArea(fix.Geometry.AsOrbGeometry())
+		applied = append(applied, AppliedZoneOverride{File: fix.File, Kind: OverrideGeometry, Original: z.Geometry})
+		z.Geometry = fix.Geometry
+		z.Center = FromOrbGeometry(centroid)
+	}
+
+	if o.matched == nil {
+		o.matched = map[string]map[string]bool{}
+	}
+
+	for i := range applied {
+		applied[i].OID = z.OID()
+		if o.matched[applied[i].File] == nil {
+			o.matched[applied[i].File] = map[string]bool{}
+		}
+		o.matched[applied[i].File][z.OID()] = true
+	}
+
+	return applied
+}
+
+// Check returns an error for every override that has not matched a zone, and for every override
+// keyed by short ID that matched more than one zone
+func (o *ZoneOverrides) Check() error {
+	if o == nil {
+		return nil
+	}
+
+	type override struct {
+		file                 string
+		unmatched, ambiguous error
+	}
+
+	var all []override
+	for _, f := range o.Geometries {
+		all = append(all, override{f.File, ErrUnmatchedGeometryFix, ErrAmbiguousGeometryFix})
+	}
+	for _, p := range o.Patches {
+		all = append(all, override{p.File, ErrUnmatchedOverride, ErrAmbiguousOverride})
+	}
+
+	var errs []error
+	for _, ov := range all {
+		oids := o.matched[ov.file]
+		switch {
+		case len(oids) == 0:
+			errs = append(errs, fmt.Errorf("%w: %s", ov.unmatched, ov.file))
+		case len(oids) > 1:
+			matched := make([]string, 0, len(oids))
+			for oid := range oids {
+				matched = append(matched, oid)
+			}
+			sort.Strings(matched)
+
+			errs = append(errs, fmt.Errorf("%w: %s matches %s", ov.ambiguous, ov.file,
+				strings.Join(matched, ", ")))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// RecordZoneOverrides replaces the recorded overrides of each zone in applied. A geometry
+// override is also recorded in zone_geometry_fixes with the geometry it replaced (see
+// [Store.AppliedGeometryFixes])
+func RecordZoneOverrides(ctx context.Context, q Querier, applied []AppliedZoneOverride, appliedAt time.Time) error {
+	cleared := map[string]bool{}
+	for _, a := range applied {
+		if !cleared[a.OID] {
+			for _, query := range []string{deleteZoneOverrides, deleteGeometryFix} {
+				if _, err := q.ExecContext(ctx, query, a.OID); err != nil {
+					return err
+				}
+			}
+			cleared[a.OID] = true
+		}
+
+		if _, err := q.ExecContext(ctx, insertZoneOverride, a.OID, a.File, a.Kind, appliedAt); err != nil {
+			return err
+		}
+
+		if a.Kind != OverrideGeometry {
+			continue
+		}
+
+		if _, err := q.ExecContext(ctx, insertGeometryFix, a.OID, a.File, appliedAt, a.Original); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AppliedZoneOverrides returns every override that changed a zone during ingest
+func (s *Store) AppliedZoneOverrides(ctx context.Context) ([]AppliedZoneOverride, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, selectZoneOverrides)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []AppliedZoneOverride
+	for rows.Next() {
+		var a AppliedZoneOverride
+		if err = rows.Scan(&a.OID, &a.File, &a.Kind, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+
+		applied = append(applied, a)
+	}
+
+	return applied, rows.Err()
+}
+
+func overrideMatches(key string, z *Zone) bool {
+	if strings.HasPrefix(key, "oid:") {
+		return key == z.OID()
+	}
+
+	return key == z.ShortID()
+}
+
+func isMetadataPatch(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), metadataPatchSuffix)
+}
+
+// mergePatch applies an RFC 7396 JSON merge patch to target
+func mergePatch(target map[string]any, patch map[string]any) {
+	for k, v := range patch {
+		if v == nil {
+			delete(target, k)
+			continue
+		}
+
+		if pv, ok := v.(map[string]any); ok {
+			tv, ok := target[k].(map[string]any)
+			if !ok {
+				tv = map[string]any{}
+			}
+
+			mergePatch(tv, pv)
+			target[k] = tv
+			continue
+		}
+
+		target[k] = v
+	}
+}
@@ -0,0 +1,85 @@
+package geodata
+
+import "context"
+
+const (
+	zoneCountyColumns = `zone_oid, county_oid, intersection_area, zone_coverage, county_coverage`
+
+	// a pair passes the threshold if it covers enough of either the zone or the county, so that a
+	// small zone is related to the large county it sits in and vice versa
+	selectCountiesForZone = `SELECT ` + zoneCountyColumns + ` FROM zone_county_pivot
+WHERE zone_oid = ?1 AND (zone_coverage >= ?2 OR county_coverage >= ?2)
+ORDER BY zone_coverage DESC, county_oid`
+	selectZonesForCounty = `SELECT ` + zoneCountyColumns + ` FROM zone_county_pivot
+WHERE county_oid = ?1 AND (zone_coverage >= ?2 OR county_coverage >= ?2)
+ORDER BY county_coverage DESC, zone_oid`
+)
+
+// ZoneCounty is a row of zone_county_pivot, relating a public, fire or marine zone to a county it
+// overlaps. Areas are in square degrees, and are only meaningful relative to each other
+type ZoneCounty struct {
+	ZoneOID          string  `json:"zoneOid"`
+	CountyOID        string  `json:"countyOid"`
+	IntersectionArea float64 `json:"intersectionArea"`
+	// ZoneCoverage is the fraction of the zone inside the county
+	ZoneCoverage float64 `json:"zoneCoverage"`
+	// CountyCoverage is the fraction of the county inside the zone
+	CountyCoverage float64 `json:"countyCoverage"`
+}
+
+// PivotOption configures a pivot table query
+type PivotOption func(o *pivotOptions)
+
+type pivotOptions struct {
+	minCoverage float64
+}
+
+// WithMinCoverage leaves out pairs where the overlap is less than fraction of both the zone and
+// the county. Slivers from boundaries that are drawn slightly differently in each dataset are
+// usually well under 1%
+func WithMinCoverage(fraction float64) PivotOption {
+	return func(o *pivotOptions) {
+		o.minCoverage = fraction
+	}
+}
+
+// CountiesForZone returns the counties that overlap the zone, most covered first
+func (s *Store) CountiesForZone(ctx context.Context, zoneOID string, opts ...PivotOption) ([]ZoneCounty, error) {
+	return s.queryZoneCounties(ctx, selectCountiesForZone, zoneOID, opts)
+}
+
+// ZonesForCounty returns the public, fire and marine zones that overlap the county, most covered
+// first
+func (s *Store) ZonesForCounty(ctx context.Context, countyOID string, opts ...PivotOption) ([]ZoneCounty, error) {
+	return s.queryZoneCounties(ctx, selectZonesForCounty, countyOID, opts)
+}
+
+func (s *Store) queryZoneCounties(ctx context.Context, query, oid string, opts []PivotOption) ([]ZoneCounty, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	var o pivotOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, oid, o.minCoverage)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pairs []ZoneCounty
+	for rows.Next() {
+		var zc ZoneCounty
+		if err = rows.Scan(&zc.ZoneOID, &zc.CountyOID, &zc.IntersectionArea, &zc.ZoneCoverage,
+			&zc.CountyCoverage); err != nil {
+			return nil, err
+		}
+
+		pairs = append(pairs, zc)
+	}
+
+	return pairs, rows.Err()
+}
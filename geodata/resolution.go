@@ -0,0 +1,173 @@
+package geodata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Resolution is a level of detail that zone geometries can be read at. Zones keep simplified
+// copies of their geometry at each level, computed when they are ingested
+type Resolution int
+
+const (
+	// ResolutionFull is the geometry as published
+	ResolutionFull Resolution = iota
+	// Resolution10m is simplified to about 10 meters, for street level maps
+	Resolution10m
+	// Resolution100m is simplified to about 100 meters, for city and county maps
+	Resolution100m
+	// Resolution1km is simplified to about 1 kilometer, for state and national maps
+	Resolution1km
+)
+
+var (
+	// ErrInvalidResolution is returned when a resolution name is not recognized
+	ErrInvalidResolution = errors.New("invalid resolution")
+
+	resolutionNames = map[Resolution]string{
+		ResolutionFull: "full",
+		Resolution10m:  "10m",
+		Resolution100m: "100m",
+		Resolution1km:  "1km",
+	}
+
+	// simplification tolerances, in degrees. A degree of latitude is about 111 km
+	resolutionTolerances = map[Resolution]float64{
+		Resolution10m:  0.0001,
+		Resolution100m: 0.001,
+		Resolution1km:  0.01,
+	}
+)
+
+const (
+	// the simplified columns are added by a later migration than zones
+	selectHasSimplifiedGeometries = `SELECT COUNT(*) > 0 FROM pragma_table_info('zones') WHERE name = 'geometry_1km'`
+
+	updateAffectedSimplifiedGeometries = `UPDATE zones
+SET geometry_10m = ST_SimplifyPreserveTopology(geometry, ?1),
+    geometry_100m = ST_SimplifyPreserveTopology(geometry, ?2),
+    geometry_1km = ST_SimplifyPreserveTopology(geometry, ?3)
+WHERE oid IN (SELECT oid FROM temp.affected_zones)`
+	insertAllZoneOIDs = `INSERT OR IGNORE INTO temp.affected_zones (oid) SELECT oid FROM zones`
+)
+
+// ParseResolution parses a resolution name: full, 10m, 100m or 1km. An empty name is full
+func ParseResolution(s string) (Resolution, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return ResolutionFull, nil
+	}
+
+	for r, name := range resolutionNames {
+		if name == s {
+			return r, nil
+		}
+	}
+
+	return ResolutionFull, fmt.Errorf("%w: %q", ErrInvalidResolution, s)
+}
+
+// ResolutionForTolerance returns the coarsest resolution whose simplification tolerance is no
+// more than tolerance degrees, such as the size of a pixel on a map
+func ResolutionForTolerance(tolerance float64) Resolution {
+	for _, r := range []Resolution{Resolution1km, Resolution100m, Resolution10m} {
+		if resolutionTolerances[r] <= tolerance {
+			return r
+		}
+	}
+
+	return ResolutionFull
+}
+
+// String returns the name of the resolution
+func (r Resolution) String() string {
+	if name, ok := resolutionNames[r]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Resolution(%d)", int(r))
+}
+
+// Tolerance returns the simplification tolerance of the resolution in degrees, or 0 for
+// [ResolutionFull]
+func (r Resolution) Tolerance() float64 {
+	return resolutionTolerances[r]
+}
+
+// Column returns the zones column that holds geometries at the resolution
+func (r Resolution) Column() string {
+	if _, ok := resolutionTolerances[r]; !ok {
+		return "geometry"
+	}
+
+	return "geometry_" + r.String()
+}
+
+// GeometryExpr returns the SQL expression that reads the geometry of a zone at the resolution,
+// falling back to the full geometry for zones that have not been simplified
+func (r Resolution) GeometryExpr() string {
+	if r.Column() == "geometry" {
+		return "geometry"
+	}
+
+	return fmt.Sprintf("COALESCE(%s, geometry)", r.Column())
+}
+
+// ZoneOption configures a query that returns zones
+type ZoneOption func(o *zoneOptions)
+
+type zoneOptions struct {
+	resolution Resolution
+}
+
+// WithResolution reads zone geometries at r instead of in full
+func WithResolution(r Resolution) ZoneOption {
+	return func(o *zoneOptions) {
+		o.resolution = r
+	}
+}
+
+func newZoneOptions(opts []ZoneOption) zoneOptions {
+	var o zoneOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// zoneColumnsAt returns zoneColumns with the geometry read at r
+func zoneColumnsAt(r Resolution) string {
+	return zoneColumnsPrefix + `, ST_AsBinary(` + r.GeometryExpr() + `)`
+}
+
+// SimplifyZoneGeometries computes the simplified geometries of every zone
+func SimplifyZoneGeometries(ctx context.Context, q Querier) error {
+	for _, query := range []string{createAffectedZones, insertAllZoneOIDs} {
+		if _, err := q.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+
+	if err := simplifyAffectedZones(ctx, q); err != nil {
+		return err
+	}
+
+	_, err := q.ExecContext(ctx, dropAffectedZones)
+	return err
+}
+
+// simplifyAffectedZones computes the simplified geometries of the zones in temp.affected_zones.
+// It does nothing before the simplified columns are added
+func simplifyAffectedZones(ctx context.Context, q Querier) error {
+	var hasColumns bool
+	if err := q.QueryRowContext(ctx, selectHasSimplifiedGeometries).Scan(&hasColumns); err != nil || !hasColumns {
+		return err
+	}
+
+	_, err := q.ExecContext(ctx, updateAffectedSimplifiedGeometries,
+		Resolution10m.Tolerance(), Resolution100m.Tolerance(), Resolution1km.Tolerance())
+	return err
+}
@@ -0,0 +1,166 @@
+package shapefile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Field describes a single attribute column in the .dbf file
+type Field struct {
+	Name     string
+	Type     byte
+	Length   int
+	Decimals int
+}
+
+var (
+	// ErrInvalidDBF is returned when the .dbf stream is malformed
+	ErrInvalidDBF = errors.New("invalid dbf")
+)
+
+const (
+	dbfHeaderSize     = 32
+	dbfFieldSize      = 32
+	dbfHeaderTerm     = 0x0D
+	dbfEOF            = 0x1A
+	dbfDeletedRecord  = '*'
+	dbfFieldNameBytes = 11
+)
+
+type dbfReader struct {
+	r          io.Reader
+	fields     []Field
+	numRecords int
+	recordLen  int
+	read       int
+}
+
+func newDBFReader(r io.Reader) (*dbfReader, error) {
+	var hdr [dbfHeaderSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDBF, err)
+	}
+
+	d := &dbfReader{
+		r:          r,
+		numRecords: int(binary.LittleEndian.Uint32(hdr[4:8])),
+		recordLen:  int(binary.LittleEndian.Uint16(hdr[10:12])),
+	}
+
+	headerLen := int(binary.LittleEndian.Uint16(hdr[8:10]))
+	if headerLen < dbfHeaderSize+1 {
+		return nil, fmt.Errorf("%w: header too short", ErrInvalidDBF)
+	}
+
+	rest := make([]byte, headerLen-dbfHeaderSize)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDBF, err)
+	}
+
+	for off := 0; off+dbfFieldSize <= len(rest) && rest[off] != dbfHeaderTerm; off += dbfFieldSize {
+		desc := rest[off : off+dbfFieldSize]
+		name, _, _ := bytes.Cut(desc[:dbfFieldNameBytes], []byte{0})
+		d.fields = append(d.fields, Field{
+			Name:     string(name),
+			Type:     desc[11],
+			Length:   int(desc[16]),
+			Decimals: int(desc[17]),
+		})
+	}
+
+	return d, nil
+}
+
+// next reads the next record and reports whether it is marked as deleted. io.EOF is returned
+// after the last record
+func (d *dbfReader) next() (map[string]any, bool, error) {
+	if d.read >= d.numRecords {
+		return nil, false, io.EOF
+	}
+
+	buf := make([]byte, d.recordLen)
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		return nil, false, fmt.Errorf("%w: record %d: %w", ErrInvalidDBF, d.read+1, err)
+	}
+	d.read++
+
+	if buf[0] == dbfEOF {
+		d.read = d.numRecords
+		return nil, false, io.EOF
+	}
+
+	attrs, err := d.decode(buf[1:])
+	return attrs, buf[0] == dbfDeletedRecord, err
+}
+
+func (d *dbfReader) decode(b []byte) (map[string]any, error) {
+	attrs := make(map[string]any, len(d.fields))
+
+	off := 0
+	for _, f := range d.fields {
+		if off+f.Length > len(b) {
+			return nil, fmt.Errorf("%w: field %s overruns record", ErrInvalidDBF, f.Name)
+		}
+
+		raw := decodeText(b[off : off+f.Length])
+		off += f.Length
+
+		attrs[f.Name] = decodeValue(f, raw)
+	}
+
+	return attrs, nil
+}
+
+func decodeValue(f Field, raw string) any {
+	switch f.Type {
+	case 'N', 'F':
+		s := strings.TrimSpace(raw)
+		if s == "" || strings.Trim(s, "*") == "" {
+			return nil
+		}
+
+		if f.Decimals == 0 {
+			if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return i
+			}
+		}
+
+		if v, err := strconv.ParseFloat(s, 64); err == nil {
+			return v
+		}
+
+		return s
+	case 'L':
+		switch strings.ToUpper(strings.TrimSpace(raw)) {
+		case "T", "Y":
+			return true
+		case "F", "N":
+			return false
+		}
+
+		return nil
+	default:
+		return strings.TrimSpace(raw)
+	}
+}
+
+// decodeText returns b as a string. Older files are usually Latin-1 rather than UTF-8, so
+// anything that is not valid UTF-8 is decoded byte-for-byte as Latin-1
+func decodeText(b []byte) string {
+	if utf8.Valid(b) {
+		return string(b)
+	}
+
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+
+	return string(runes)
+}
@@ -0,0 +1,212 @@
+// Package shapefile reads ESRI shapefiles, including the zipped .shp/.dbf/.prj bundles published
+// by the NWS and the Census Bureau, into [github.com/paulmach/orb/geojson.Feature] values
+package shapefile
+
+import (
+	"archive/zip"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+var (
+	// ErrMissingMember is returned when a zip archive does not contain a required file
+	ErrMissingMember = errors.New("shapefile member missing from archive")
+	// ErrUnsupportedProjection is returned when the .prj describes a projected coordinate
+	// system. Only geographic (longitude/latitude) coordinates are supported
+	ErrUnsupportedProjection = errors.New("unsupported projection")
+)
+
+// Reader reads features from a shapefile one record at a time
+type Reader struct {
+	shp     io.Reader
+	dbf     *dbfReader
+	header  *shpHeader
+	closers []io.Closer
+}
+
+// NewReader creates a [Reader] from the contents of a .shp and .dbf file. prj is the contents of
+// the .prj file, and may be empty if the shapefile has none, in which case coordinates are
+// assumed to be longitude/latitude
+func NewReader(shp, dbf io.Reader, prj []byte) (*Reader, error) {
+	if err := checkProjection(prj); err != nil {
+		return nil, err
+	}
+
+	shp = bufio.NewReader(shp)
+	header, err := readShpHeader(shp)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := newDBFReader(bufio.NewReader(dbf))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{shp: shp, dbf: d, header: header}, nil
+}
+
+// OpenZip opens a zipped shapefile. If the archive holds more than one layer, the first .shp
+// in the archive is read
+func OpenZip(file string) (*Reader, error) {
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := newZipReader(&zr.Reader)
+	if err != nil {
+		zr.Close()
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	r.closers = append(r.closers, zr)
+	return r, nil
+}
+
+func newZipReader(zr *zip.Reader) (*Reader, error) {
+	members := map[string]*zip.File{}
+	var layer string
+	for _, f := range zr.File {
+		ext := strings.ToLower(path.Ext(f.Name))
+		base := strings.TrimSuffix(f.Name, path.Ext(f.Name))
+		if ext == ".shp" && layer == "" {
+			layer = base
+		}
+		members[base+ext] = f
+	}
+
+	if layer == "" {
+		return nil, fmt.Errorf("%w: .shp", ErrMissingMember)
+	}
+
+	var prj []byte
+	if f, ok := members[layer+".prj"]; ok {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		prj, err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var closers []io.Closer
+	open := func(ext string) (io.Reader, error) {
+		f, ok := members[layer+ext]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrMissingMember, ext)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		closers = append(closers, rc)
+		return rc, nil
+	}
+
+	closeAll := func() {
+		for _, c := range closers {
+			c.Close()
+		}
+	}
+
+	shp, err := open(".shp")
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+
+	dbf, err := open(".dbf")
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+
+	r, err := NewReader(shp, dbf, prj)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+
+	r.closers = closers
+	return r, nil
+}
+
+// ShapeType returns the shape type declared in the .shp header
+func (r *Reader) ShapeType() ShapeType {
+	return r.header.shapeType
+}
+
+// Bound returns the bounding box declared in the .shp header
+func (r *Reader) Bound() orb.Bound {
+	return r.header.bound
+}
+
+// Fields returns the attribute columns of the .dbf file
+func (r *Reader) Fields() []Field {
+	return r.dbf.fields
+}
+
+// Next returns the next feature, with the record number as its ID and the .dbf attributes as its
+// properties. It returns io.EOF when there are no more records. Records with a null shape are
+// returned with a nil geometry, and records marked as deleted in the .dbf are skipped
+func (r *Reader) Next() (*geojson.Feature, error) {
+	for {
+		num, g, err := readShpRecord(r.shp)
+		if err != nil {
+			return nil, err
+		}
+
+		attrs, deleted, err := r.dbf.next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("%w: record %d has no attributes", ErrInvalidDBF, num)
+			}
+
+			return nil, err
+		}
+
+		if deleted {
+			continue
+		}
+
+		f := geojson.NewFeature(g)
+		f.ID = num
+		f.Properties = attrs
+
+		return f, nil
+	}
+}
+
+// Close releases any files opened by [OpenZip]
+func (r *Reader) Close() error {
+	var errs []error
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		errs = append(errs, r.closers[i].Close())
+	}
+	r.closers = nil
+
+	return errors.Join(errs...)
+}
+
+func checkProjection(prj []byte) error {
+	wkt := strings.TrimSpace(string(prj))
+	if wkt == "" || strings.HasPrefix(strings.ToUpper(wkt), "GEOGCS") {
+		return nil
+	}
+
+	name, _, _ := strings.Cut(wkt, ",")
+	return fmt.Errorf("%w: %s", ErrUnsupportedProjection, name)
+}
@@ -0,0 +1,306 @@
+package shapefile
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+func openTestReader(t *testing.T) *Reader {
+	t.Helper()
+
+	shp, err := os.Open("testdata/zones.shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { shp.Close() })
+
+	dbf, err := os.Open("testdata/zones.dbf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { dbf.Close() })
+
+	prj, err := os.ReadFile("testdata/zones.prj")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(shp, dbf, prj)
+	if err != nil {
+		t.Fatalf("NewReader() = %v", err)
+	}
+
+	return r
+}
+
+func readAll(t *testing.T, r *Reader) []*geojson.Feature {
+	t.Helper()
+
+	var features []*geojson.Feature
+	for {
+		f, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return features
+		}
+
+		if err != nil {
+			t.Fatalf("Next() = %v", err)
+		}
+
+		features = append(features, f)
+	}
+}
+
+func TestReader(t *testing.T) {
+	r := openTestReader(t)
+
+	if r.ShapeType() != Polygon {
+		t.Errorf("ShapeType() = %d, want %d", r.ShapeType(), Polygon)
+	}
+
+	if want := (orb.Bound{Min: orb.Point{-106.9, 0}, Max: orb.Point{41, 10}}); r.Bound() != want {
+		t.Errorf("Bound() = %v, want %v", r.Bound(), want)
+	}
+
+	if len(r.Fields()) != 5 || r.Fields()[3] != (Field{Name: "AREA", Type: 'N', Length: 10, Decimals: 3}) {
+		t.Errorf("Fields() = %+v, want five fields with AREA fourth", r.Fields())
+	}
+
+	features := readAll(t, r)
+
+	// record 5 is deleted in the .dbf
+	tests := []struct {
+		id       int
+		geometry orb.Geometry
+		props    geojson.Properties
+	}{
+		{
+			id: 1,
+			geometry: orb.Polygon{
+				{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+				{{2, 2}, {2, 4}, {4, 4}, {4, 2}, {2, 2}},
+			},
+			props: geojson.Properties{"NAME": "Hamilton", "STATE": "OH", "POP": int64(830639), "AREA": 407.36, "ACTIVE": true},
+		},
+		{
+			id: 2,
+			geometry: orb.MultiPolygon{
+				{{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}},
+				{
+					{{20, 0}, {30, 0}, {30, 10}, {20, 10}, {20, 0}},
+					{{22, 2}, {22, 4}, {24, 4}, {24, 2}, {22, 2}},
+				},
+			},
+			// blank and overflowed numbers, and unknown logicals, are null
+			props: geojson.Properties{"NAME": "Lake Erie", "STATE": "OH", "POP": nil, "AREA": nil, "ACTIVE": nil},
+		},
+		{
+			id:       3,
+			geometry: orb.Polygon{{{40, 0}, {41, 0}, {41, 1}, {40, 1}, {40, 0}}},
+			props:    geojson.Properties{"NAME": "Orphan", "STATE": "OH", "POP": int64(12), "AREA": 1.5, "ACTIVE": false},
+		},
+		{
+			id:    4,
+			props: geojson.Properties{"NAME": "Null", "STATE": "OH", "POP": int64(0), "AREA": 0.0, "ACTIVE": false},
+		},
+		{
+			id: 6,
+			geometry: orb.Polygon{
+				{{-106.9, 32}, {-106.3, 32}, {-106.3, 32.9}, {-106.9, 32.9}, {-106.9, 32}},
+			},
+			props: geojson.Properties{"NAME": "Doña Ana", "STATE": "NM", "POP": int64(219561), "AREA": 3814.8, "ACTIVE": true},
+		},
+	}
+
+	if len(features) != len(tests) {
+		t.Fatalf("read %d features, want %d", len(features), len(tests))
+	}
+
+	for i, tt := range tests {
+		f := features[i]
+		if f.ID != tt.id {
+			t.Errorf("feature %d ID = %v, want %d", i, f.ID, tt.id)
+		}
+
+		if !reflect.DeepEqual(f.Geometry, tt.geometry) {
+			t.Errorf("feature %d geometry = %v, want %v", tt.id, f.Geometry, tt.geometry)
+		}
+
+		if !reflect.DeepEqual(f.Properties, tt.props) {
+			t.Errorf("feature %d properties = %v, want %v", tt.id, f.Properties, tt.props)
+		}
+	}
+}
+
+func TestAssemblePolygons(t *testing.T) {
+	cw := func(minX, minY, maxX, maxY float64) []orb.Point {
+		return []orb.Point{{minX, minY}, {minX, maxY}, {maxX, maxY}, {maxX, minY}, {minX, minY}}
+	}
+	ccw := func(minX, minY, maxX, maxY float64) []orb.Point {
+		return []orb.Point{{minX, minY}, {maxX, minY}, {maxX, maxY}, {minX, maxY}, {minX, minY}}
+	}
+
+	tests := []struct {
+		name  string
+		parts [][]orb.Point
+		want  orb.Geometry
+	}{
+		{
+			name:  "outer ring is rewound counter-clockwise",
+			parts: [][]orb.Point{cw(0, 0, 1, 1)},
+			want:  orb.Polygon{ccw(0, 0, 1, 1)},
+		},
+		{
+			name:  "hole is rewound clockwise",
+			parts: [][]orb.Point{cw(0, 0, 10, 10), ccw(2, 2, 4, 4)},
+			want:  orb.Polygon{ccw(0, 0, 10, 10), cw(2, 2, 4, 4)},
+		},
+		{
+			name:  "hole before its outer ring",
+			parts: [][]orb.Point{ccw(2, 2, 4, 4), cw(0, 0, 10, 10)},
+			want:  orb.Polygon{ccw(0, 0, 10, 10), cw(2, 2, 4, 4)},
+		},
+		{
+			name:  "hole goes to the outer ring that contains it",
+			parts: [][]orb.Point{cw(0, 0, 1, 1), cw(20, 0, 30, 10), ccw(22, 2, 24, 4)},
+			want: orb.MultiPolygon{
+				{ccw(0, 0, 1, 1)},
+				{ccw(20, 0, 30, 10), cw(22, 2, 24, 4)},
+			},
+		},
+		{
+			name:  "orphaned hole becomes an outer ring",
+			parts: [][]orb.Point{cw(0, 0, 1, 1), ccw(5, 5, 6, 6)},
+			want:  orb.MultiPolygon{{ccw(0, 0, 1, 1)}, {ccw(5, 5, 6, 6)}},
+		},
+		{
+			name:  "degenerate rings are dropped",
+			parts: [][]orb.Point{cw(0, 0, 1, 1), {{5, 5}, {6, 6}, {5, 5}}},
+			want:  orb.Polygon{ccw(0, 0, 1, 1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := assemblePolygons(tt.parts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("assemblePolygons() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name string
+		b    []byte
+		want string
+	}{
+		{name: "ASCII", b: []byte("Hamilton"), want: "Hamilton"},
+		{name: "UTF-8", b: []byte("Doña Ana"), want: "Doña Ana"},
+		{name: "Latin-1", b: []byte("Do\xf1a Ana"), want: "Doña Ana"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeText(tt.b); got != tt.want {
+				t.Errorf("decodeText(%q) = %q, want %q", tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestZipReader(t *testing.T) {
+	zipped := func(names ...string) *zip.Reader {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for _, name := range names {
+			data, err := os.ReadFile("testdata/" + name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w, err := zw.Create("c_18mr25/" + name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err = w.Write(data); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return zr
+	}
+
+	r, err := newZipReader(zipped("zones.prj", "zones.shp", "zones.dbf"))
+	if err != nil {
+		t.Fatalf("newZipReader() = %v", err)
+	}
+
+	if features := readAll(t, r); len(features) != 5 {
+		t.Errorf("read %d features, want 5", len(features))
+	}
+
+	if err = r.Close(); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+
+	if _, err = newZipReader(zipped("zones.shp")); !errors.Is(err, ErrMissingMember) {
+		t.Errorf("newZipReader() without a .dbf = %v, want %v", err, ErrMissingMember)
+	}
+}
+
+func TestNewReaderErrors(t *testing.T) {
+	shp, err := os.ReadFile("testdata/zones.shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dbf, err := os.ReadFile("testdata/zones.dbf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = NewReader(bytes.NewReader(shp), bytes.NewReader(dbf), []byte(`PROJCS["NAD_1983_UTM_Zone_17N",GEOGCS[]]`)); !errors.Is(err, ErrUnsupportedProjection) {
+		t.Errorf("NewReader() with a projected .prj = %v, want %v", err, ErrUnsupportedProjection)
+	}
+
+	if _, err = NewReader(bytes.NewReader(dbf), bytes.NewReader(dbf), nil); !errors.Is(err, ErrInvalidShapefile) {
+		t.Errorf("NewReader() with a .dbf for the .shp = %v, want %v", err, ErrInvalidShapefile)
+	}
+
+	if _, err = NewReader(bytes.NewReader(shp), bytes.NewReader(dbf[:10]), nil); !errors.Is(err, ErrInvalidDBF) {
+		t.Errorf("NewReader() with a truncated .dbf = %v, want %v", err, ErrInvalidDBF)
+	}
+
+	// a .shp cut off in the middle of a record
+	r, err := NewReader(bytes.NewReader(shp[:len(shp)-20]), bytes.NewReader(dbf), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for err == nil {
+		_, err = r.Next()
+	}
+
+	if !errors.Is(err, ErrInvalidShapefile) {
+		t.Errorf("Next() on a truncated .shp = %v, want %v", err, ErrInvalidShapefile)
+	}
+}
@@ -0,0 +1,254 @@
+package shapefile
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+)
+
+// ShapeType is the geometry type code stored in the .shp header and in each record
+type ShapeType int32
+
+// The shape types defined by the ESRI Shapefile Technical Description
+const (
+	NullShape   ShapeType = 0
+	Point       ShapeType = 1
+	PolyLine    ShapeType = 3
+	Polygon     ShapeType = 5
+	MultiPoint  ShapeType = 8
+	PointZ      ShapeType = 11
+	PolyLineZ   ShapeType = 13
+	PolygonZ    ShapeType = 15
+	MultiPointZ ShapeType = 18
+	PointM      ShapeType = 21
+	PolyLineM   ShapeType = 23
+	PolygonM    ShapeType = 25
+	MultiPointM ShapeType = 28
+)
+
+const (
+	shpFileCode   = 9994
+	shpHeaderSize = 100
+)
+
+var (
+	// ErrInvalidShapefile is returned when the .shp stream is malformed
+	ErrInvalidShapefile = errors.New("invalid shapefile")
+	// ErrUnsupportedShapeType is returned for shape types such as MultiPatch that have no orb equivalent
+	ErrUnsupportedShapeType = errors.New("unsupported shape type")
+)
+
+type shpHeader struct {
+	shapeType ShapeType
+	bound     orb.Bound
+}
+
+func readShpHeader(r io.Reader) (*shpHeader, error) {
+	var buf [shpHeaderSize]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidShapefile, err)
+	}
+
+	if binary.BigEndian.Uint32(buf[0:4]) != shpFileCode {
+		return nil, fmt.Errorf("%w: bad file code", ErrInvalidShapefile)
+	}
+
+	return &shpHeader{
+		shapeType: ShapeType(binary.LittleEndian.Uint32(buf[32:36])),
+		bound: orb.Bound{
+			Min: orb.Point{readFloat(buf[36:]), readFloat(buf[44:])},
+			Max: orb.Point{readFloat(buf[52:]), readFloat(buf[60:])},
+		},
+	}, nil
+}
+
+// readShpRecord reads the next record, returning its 1-based record number and geometry. The
+// geometry is nil for null shapes. io.EOF is returned cleanly at the end of the stream
+func readShpRecord(r io.Reader) (int, orb.Geometry, error) {
+	var hdr [8]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, nil, io.EOF
+		}
+
+		return 0, nil, fmt.Errorf("%w: %w", ErrInvalidShapefile, err)
+	}
+
+	num := int(binary.BigEndian.Uint32(hdr[0:4]))
+	// content length is measured in 16-bit words
+	content := make([]byte, int(binary.BigEndian.Uint32(hdr[4:8]))*2)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return 0, nil, fmt.Errorf("%w: record %d: %w", ErrInvalidShapefile, num, err)
+	}
+
+	g, err := decodeShape(content)
+	if err != nil {
+		return 0, nil, fmt.Errorf("record %d: %w", num, err)
+	}
+
+	return num, g, nil
+}
+
+// decodeShape decodes the X/Y portion of a record's content. Z and M values, which follow the
+// X/Y data for the Z and M shape types, are ignored
+func decodeShape(b []byte) (orb.Geometry, error) {
+	if len(b) < 4 {
+		return nil, fmt.Errorf("%w: truncated record", ErrInvalidShapefile)
+	}
+
+	st := ShapeType(binary.LittleEndian.Uint32(b[0:4]))
+	b = b[4:]
+
+	switch st {
+	case NullShape:
+		return nil, nil
+	case Point, PointZ, PointM:
+		if len(b) < 16 {
+			return nil, fmt.Errorf("%w: truncated point", ErrInvalidShapefile)
+		}
+
+		return orb.Point{readFloat(b[0:]), readFloat(b[8:])}, nil
+	case MultiPoint, MultiPointZ, MultiPointM:
+		// skip the bounding box
+		if len(b) < 36 {
+			return nil, fmt.Errorf("%w: truncated multipoint", ErrInvalidShapefile)
+		}
+
+		n := int(binary.LittleEndian.Uint32(b[32:36]))
+		pts, err := readPoints(b[36:], n)
+		if err != nil {
+			return nil, err
+		}
+
+		return orb.MultiPoint(pts), nil
+	case PolyLine, PolyLineZ, PolyLineM, Polygon, PolygonZ, PolygonM:
+		parts, err := readParts(b)
+		if err != nil {
+			return nil, err
+		}
+
+		switch st {
+		case PolyLine, PolyLineZ, PolyLineM:
+			mls := make(orb.MultiLineString, 0, len(parts))
+			for _, part := range parts {
+				mls = append(mls, orb.LineString(part))
+			}
+
+			if len(mls) == 1 {
+				return mls[0], nil
+			}
+
+			return mls, nil
+		default:
+			return assemblePolygons(parts), nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %d", ErrUnsupportedShapeType, st)
+}
+
+// readParts reads the parts of a PolyLine or Polygon record, skipping its bounding box
+func readParts(b []byte) ([][]orb.Point, error) {
+	if len(b) < 40 {
+		return nil, fmt.Errorf("%w: truncated parts", ErrInvalidShapefile)
+	}
+
+	numParts := int(binary.LittleEndian.Uint32(b[32:36]))
+	numPoints := int(binary.LittleEndian.Uint32(b[36:40]))
+	b = b[40:]
+
+	if len(b) < numParts*4 {
+		return nil, fmt.Errorf("%w: truncated part index", ErrInvalidShapefile)
+	}
+
+	starts := make([]int, numParts+1)
+	for i := range numParts {
+		starts[i] = int(binary.LittleEndian.Uint32(b[i*4:]))
+	}
+	starts[numParts] = numPoints
+
+	pts, err := readPoints(b[numParts*4:], numPoints)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make([][]orb.Point, 0, numParts)
+	for i := range numParts {
+		if starts[i] < 0 || starts[i] > starts[i+1] || starts[i+1] > numPoints {
+			return nil, fmt.Errorf("%w: bad part index", ErrInvalidShapefile)
+		}
+
+		parts = append(parts, pts[starts[i]:starts[i+1]])
+	}
+
+	return parts, nil
+}
+
+func readPoints(b []byte, n int) ([]orb.Point, error) {
+	if n < 0 || len(b) < n*16 {
+		return nil, fmt.Errorf("%w: truncated points", ErrInvalidShapefile)
+	}
+
+	pts := make([]orb.Point, n)
+	for i := range pts {
+		pts[i] = orb.Point{readFloat(b[i*16:]), readFloat(b[i*16+8:])}
+	}
+
+	return pts, nil
+}
+
+// assemblePolygons groups shapefile rings into polygons. Shapefiles store outer rings clockwise
+// and holes counter-clockwise, with no explicit link between a hole and its outer ring, so each
+// hole is assigned to the first outer ring that contains it. The result uses the RFC 7946 winding
+// order (counter-clockwise outer rings)
+func assemblePolygons(parts [][]orb.Point) orb.Geometry {
+	var outers []orb.Polygon
+	var holes []orb.Ring
+
+	for _, part := range parts {
+		ring := orb.Ring(part)
+		if len(ring) < 4 {
+			continue
+		}
+
+		if ring.Orientation() == orb.CW {
+			ring.Reverse()
+			outers = append(outers, orb.Polygon{ring})
+		} else {
+			ring.Reverse()
+			holes = append(holes, ring)
+		}
+	}
+
+	for _, hole := range holes {
+		assigned := false
+		for i := range outers {
+			if planar.RingContains(outers[i][0], hole[0]) {
+				outers[i] = append(outers[i], hole)
+				assigned = true
+				break
+			}
+		}
+
+		if !assigned {
+			// an orphaned hole is almost always an outer ring with the wrong winding
+			hole.Reverse()
+			outers = append(outers, orb.Polygon{hole})
+		}
+	}
+
+	if len(outers) == 1 {
+		return outers[0]
+	}
+
+	return orb.MultiPolygon(outers)
+}
+
+func readFloat(b []byte) float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(b))
+}
@@ -0,0 +1,115 @@
+package geodata
+
+import (
+	"context"
+	"sort"
+)
+
+const (
+	// the spatial index narrows candidates to those whose bounding box meets the search geometry
+	selectZonesIntersecting = `SELECT oid, type, coverage
+FROM (SELECT z.oid, z.type,
+             ST_Area(ST_Intersection(z.geometry, g.geom)) / ST_Area(z.geometry) AS coverage
+      FROM zones z, (SELECT GeosMakeValid(ST_GeomFromWKB(?1, 4326)) AS geom) g
+      WHERE z.retired_at IS NULL
+        AND z.ROWID IN (SELECT ROWID FROM SpatialIndex
+                        WHERE f_table_name = 'zones' AND f_geometry_column = 'geometry' AND search_frame = g.geom)
+        AND ST_Intersects(z.geometry, g.geom))
+WHERE coverage > 0 AND coverage >= ?2
+ORDER BY coverage DESC, oid`
+	selectZipAreasWithin = `SELECT code, coverage
+FROM (SELECT zc.code, ST_Area(ST_Intersection(zc.area, g.geom)) / ST_Area(zc.area) AS coverage
+      FROM us_zip_codes zc, (SELECT GeosMakeValid(ST_GeomFromWKB(?1, 4326)) AS geom) g
+      WHERE zc.area IS NOT NULL
+        AND zc.ROWID IN (SELECT ROWID FROM SpatialIndex
+                         WHERE f_table_name = 'us_zip_codes' AND f_geometry_column = 'area' AND search_frame = g.geom)
+        AND ST_Intersects(zc.area, g.geom))
+WHERE coverage > 0`
+	// zip codes with no ZCTA polygon are inside the geometry if their center is
+	selectZipCentersWithin = `SELECT zc.code, 1
+FROM us_zip_codes zc, (SELECT GeosMakeValid(ST_GeomFromWKB(?1, 4326)) AS geom) g
+WHERE zc.area IS NULL
+  AND zc.ROWID IN (SELECT ROWID FROM SpatialIndex
+                   WHERE f_table_name = 'us_zip_codes' AND f_geometry_column = 'center' AND search_frame = g.geom)
+  AND ST_Intersects(zc.center, g.geom)`
+)
+
+// ZoneCoverage is a zone that a geometry overlaps, and the fraction of the zone it covers
+type ZoneCoverage struct {
+	OID      string  `json:"oid"`
+	Type     string  `json:"type"`
+	Coverage float64 `json:"coverage"`
+}
+
+// ZipCoverage is a zip code that a geometry overlaps, and the fraction of the zip code area it
+// covers. Zip codes without a ZCTA polygon have a coverage of 1 if their center is inside
+type ZipCoverage struct {
+	Code     string  `json:"code"`
+	Coverage float64 `json:"coverage"`
+}
+
+// ZonesIntersecting returns the active zones that geom overlaps by at least minCoverage of their
+// area, most covered first. Zones that only touch geom are never returned. This is how a
+// storm-based warning polygon is narrowed to the zones inside it
+func (s *Store) ZonesIntersecting(ctx context.Context, geom *Geometry, minCoverage float64) ([]ZoneCoverage, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, selectZonesIntersecting, geom, minCoverage)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var zones []ZoneCoverage
+	for rows.Next() {
+		var zc ZoneCoverage
+		if err = rows.Scan(&zc.OID, &zc.Type, &zc.Coverage); err != nil {
+			return nil, err
+		}
+
+		zones = append(zones, zc)
+	}
+
+	return zones, rows.Err()
+}
+
+// ZipsWithin returns the zip codes that geom overlaps, most covered first
+func (s *Store) ZipsWithin(ctx context.Context, geom *Geometry) ([]ZipCoverage, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	var zips []ZipCoverage
+	for _, query := range []string{selectZipAreasWithin, selectZipCentersWithin} {
+		rows, err := s.db.QueryContext(ctx, query, geom)
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			var zc ZipCoverage
+			if err = rows.Scan(&zc.Code, &zc.Coverage); err != nil {
+				rows.Close()
+				return nil, err
+			}
+
+			zips = append(zips, zc)
+		}
+		rows.Close()
+
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(zips, func(i, j int) bool {
+		if zips[i].Coverage != zips[j].Coverage {
+			return zips[i].Coverage > zips[j].Coverage
+		}
+		return zips[i].Code < zips[j].Code
+	})
+
+	return zips, nil
+}
@@ -0,0 +1,93 @@
+package geodata
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/watchedsky-social/libwatchedsky"
+)
+
+// Store provides queries and updates against a geodata DB. The DB must be opened with the
+// spatialite driver from [github.com/watchedsky-social/go-spatialite], and have had the
+// migrations in [github.com/watchedsky-social/libwatchedsky/geodata/migrations] applied
+type Store struct {
+	db *sql.DB
+}
+
+const (
+	// zoneColumns are the columns read by scanZone, in order
+	zoneColumns       = zoneColumnsPrefix + `, ST_AsBinary(geometry)`
+	zoneColumnsPrefix = `oid, id, name, type, metadata, ST_AsBinary(center)`
+
+	selectZone = `SELECT %s FROM zones WHERE oid = ?`
+)
+
+var (
+	// ErrZoneNotFound is returned when no zone matches a lookup
+	ErrZoneNotFound = errors.New("zone not found")
+)
+
+// NewStore creates a [Store] backed by db
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// DB returns the underlying DB
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+func checkContext(ctx context.Context) error {
+	if ctx == nil {
+		return libwatchedsky.ErrNilContext
+	}
+
+	return nil
+}
+
+// Zone returns the current version of the zone with the given OID, or [ErrZoneNotFound]
+func (s *Store) Zone(ctx context.Context, oid string, opts ...ZoneOption) (*Zone, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	o := newZoneOptions(opts)
+	z, err := scanZone(s.db.QueryRowContext(ctx, fmt.Sprintf(selectZone, zoneColumnsAt(o.resolution)), oid))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrZoneNotFound
+	}
+
+	return z, err
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanZone scans a row selected with zoneColumns
+func scanZone(row scanner) (*Zone, error) {
+	var (
+		z        Zone
+		metadata []byte
+		center   Geometry
+		geometry Geometry
+	)
+
+	if err := row.Scan(&z.oid, &z.ID, &z.Name, &z.Type, &metadata, &center, &geometry); err != nil {
+		return nil, err
+	}
+
+	if metadata != nil {
+		if err := json.Unmarshal(metadata, &z.Metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	z.Center = FromOrbGeometry(center.g)
+	z.Geometry = FromOrbGeometry(geometry.g)
+
+	return &z, nil
+}
@@ -0,0 +1,244 @@
+package geodata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/watchedsky-social/libwatchedsky/geodata/shapefile"
+)
+
+// MinTimeZoneCoverage is the smallest fraction of a zone or zip code area that a time zone must
+// cover to be assigned to it, so that boundaries drawn slightly differently in each dataset do
+// not make every border zone straddle two time zones
+const MinTimeZoneCoverage = 0.01
+
+var (
+	// ErrTimeZoneNotFound is returned when no time zone is assigned to an OID
+	ErrTimeZoneNotFound = errors.New("time zone not found")
+)
+
+const (
+	insertTimeZoneQuery = `INSERT INTO time_zones (tzid, geometry) VALUES (?, GeosMakeValid(ST_GeomFromWKB(?, 4326)))`
+
+	deleteAffectedZoneTimeZones = `DELETE FROM zone_time_zones WHERE zone_oid IN (SELECT oid FROM temp.affected_zones)`
+	// candidate time zones come from the spatial index, since their polygons are large
+	insertAffectedZoneTimeZones = `INSERT OR IGNORE INTO zone_time_zones (zone_oid, tzid, coverage)
+SELECT oid, tzid, coverage
+FROM (SELECT z.oid, t.tzid, ST_Area(ST_Intersection(z.geometry, t.geometry)) / ST_Area(z.geometry) AS coverage
+      FROM zones z, time_zones t
+      WHERE z.oid IN (SELECT oid FROM temp.affected_zones) AND z.geometry IS NOT NULL AND ST_Area(z.geometry) > 0
+        AND t.ROWID IN (SELECT ROWID FROM SpatialIndex
+                            WHERE f_table_name = 'time_zones' AND f_geometry_column = 'geometry' AND search_frame = z.geometry)
+        AND ST_Intersects(z.geometry, t.geometry))
+WHERE coverage >= ?1`
+	updateAffectedZoneTZ = `UPDATE zones
+SET tz = (SELECT tzid FROM zone_time_zones p WHERE p.zone_oid = zones.oid ORDER BY coverage DESC, tzid LIMIT 1)
+WHERE oid IN (SELECT oid FROM temp.affected_zones)`
+
+	deleteAffectedZipTimeZones = `DELETE FROM zip_time_zones WHERE zip_code IN (SELECT code FROM temp.affected_zips)`
+	insertZipAreaTimeZones     = `INSERT OR IGNORE INTO zip_time_zones (zip_code, tzid, coverage)
+SELECT code, tzid, coverage
+FROM (SELECT zc.code, t.tzid, ST_Area(ST_Intersection(zc.area, t.geometry)) / ST_Area(zc.area) AS coverage
+      FROM us_zip_codes zc, time_zones t
+      WHERE zc.code IN (SELECT code FROM temp.affected_zips) AND zc.area IS NOT NULL AND ST_Area(zc.area) > 0
+        AND t.ROWID IN (SELECT ROWID FROM SpatialIndex
+                            WHERE f_table_name = 'time_zones' AND f_geometry_column = 'geometry' AND search_frame = zc.area)
+        AND ST_Intersects(zc.area, t.geometry))
+WHERE coverage >= ?1`
+	insertZipCenterTimeZones = `INSERT OR IGNORE INTO zip_time_zones (zip_code, tzid, coverage)
+SELECT zc.code, t.tzid, 1
+FROM us_zip_codes zc, time_zones t
+WHERE zc.code IN (SELECT code FROM temp.affected_zips)
+  AND NOT EXISTS (SELECT 1 FROM zip_time_zones p WHERE p.zip_code = zc.code)
+  AND t.ROWID IN (SELECT ROWID FROM SpatialIndex
+                      WHERE f_table_name = 'time_zones' AND f_geometry_column = 'geometry' AND search_frame = zc.center)
+  AND ST_Intersects(zc.center, t.geometry)`
+	updateAffectedZipTZ = `UPDATE us_zip_codes
+SET tz = (SELECT tzid FROM zip_time_zones p WHERE p.zip_code = us_zip_codes.code ORDER BY coverage DESC, tzid LIMIT 1)
+WHERE code IN (SELECT code FROM temp.affected_zips)`
+
+	// zones can be updated before the time zone tables are created by a later migration
+	selectHasTimeZones = `SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = 'zone_time_zones'`
+
+	insertAllZones = `INSERT OR IGNORE INTO temp.affected_zones (oid) SELECT oid FROM zones WHERE retired_at IS NULL`
+
+	selectZoneTimeZones = `SELECT tzid FROM zone_time_zones WHERE zone_oid = ? ORDER BY coverage DESC, tzid`
+	selectZipTimeZones  = `SELECT tzid FROM zip_time_zones WHERE zip_code = ? ORDER BY coverage DESC, tzid`
+)
+
+// OpenTimeZones opens a file of IANA time zone boundaries, as released by
+// timezone-boundary-builder, either as GeoJSON or as a zipped shapefile. Each feature has the
+// time zone name in its "tzid" property
+func OpenTimeZones(file string) (FeatureReadCloser, error) {
+	if strings.EqualFold(filepath.Ext(file), ".zip") {
+		return shapefile.OpenZip(file)
+	}
+
+	return OpenGeoJSON(file)
+}
+
+// InsertTimeZones inserts every time zone boundary read from r. Features whose tzid is not in the
+// local time zone database are skipped, since [Store.TimeZoneFor] could not load them
+func InsertTimeZones(ctx context.Context, q Querier, r FeatureReader) (int, error) {
+	n := 0
+	for {
+		f, err := r.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return n, nil
+			}
+
+			return n, err
+		}
+
+		tzid := f.Properties.MustString("tzid", "")
+		if tzid == "" || f.Geometry == nil {
+			continue
+		}
+
+		if _, err = time.LoadLocation(tzid); err != nil {
+			continue
+		}
+
+		if _, err = q.ExecContext(ctx, insertTimeZoneQuery, tzid, FromOrbGeometry(f.Geometry)); err != nil {
+			return n, fmt.Errorf("%s: %w", tzid, err)
+		}
+		n++
+	}
+}
+
+// AssignTimeZones assigns time zones to every active zone and every zip code. Each is related in
+// zone_time_zones or zip_time_zones to every time zone that covers at least
+// [MinTimeZoneCoverage] of it, and its tz column is set to the one that covers the most. Zip
+// codes without a ZCTA polygon use the time zone their center is in
+func AssignTimeZones(ctx context.Context, q Querier) error {
+	for _, query := range []string{createAffectedZones, insertAllZones, createAffectedZips, insertAllZips} {
+		if _, err := q.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+
+	if err := assignAffectedTimeZones(ctx, q); err != nil {
+		return err
+	}
+
+	for _, query := range []string{dropAffectedZips, dropAffectedZones} {
+		if _, err := q.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// assignAffectedTimeZones assigns time zones to the zones in temp.affected_zones and the zip
+// codes in temp.affected_zips
+func assignAffectedTimeZones(ctx context.Context, q Querier) error {
+	if err := assignAffectedZoneTimeZones(ctx, q); err != nil {
+		return err
+	}
+
+	steps := []struct {
+		query string
+		args  []any
+	}{
+		{deleteAffectedZipTimeZones, nil},
+		{insertZipAreaTimeZones, []any{MinTimeZoneCoverage}},
+		{insertZipCenterTimeZones, nil},
+		{updateAffectedZipTZ, nil},
+	}
+
+	for _, step := range steps {
+		if _, err := q.ExecContext(ctx, step.query, step.args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// assignAffectedZoneTimeZones assigns time zones to the zones in temp.affected_zones. It does
+// nothing before the time zone tables are created
+func assignAffectedZoneTimeZones(ctx context.Context, q Querier) error {
+	var hasTimeZones bool
+	if err := q.QueryRowContext(ctx, selectHasTimeZones).Scan(&hasTimeZones); err != nil || !hasTimeZones {
+		return err
+	}
+
+	steps := []struct {
+		query string
+		args  []any
+	}{
+		{deleteAffectedZoneTimeZones, nil},
+		{insertAffectedZoneTimeZones, []any{MinTimeZoneCoverage}},
+		{updateAffectedZoneTZ, nil},
+	}
+
+	for _, step := range steps {
+		if _, err := q.ExecContext(ctx, step.query, step.args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TimeZoneFor returns the time zone of a zone or zip code OID. For one that straddles time zones,
+// this is the one covering most of it; see [Store.TimeZonesFor]
+func (s *Store) TimeZoneFor(ctx context.Context, oid string) (*time.Location, error) {
+	locs, err := s.TimeZonesFor(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+
+	return locs[0], nil
+}
+
+// TimeZonesFor returns every time zone of a zone or zip code OID, the one covering most of it
+// first, or [ErrTimeZoneNotFound]
+func (s *Store) TimeZonesFor(ctx context.Context, oid string) ([]*time.Location, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	query, key := selectZoneTimeZones, oid
+	if parts := strings.Split(oid, ":"); len(parts) == 6 && parts[4] == "zip" {
+		query, key = selectZipTimeZones, parts[5]
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var locs []*time.Location
+	for rows.Next() {
+		var tzid string
+		if err = rows.Scan(&tzid); err != nil {
+			return nil, err
+		}
+
+		loc, err := time.LoadLocation(tzid)
+		if err != nil {
+			return nil, err
+		}
+
+		locs = append(locs, loc)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(locs) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTimeZoneNotFound, oid)
+	}
+
+	return locs, nil
+}
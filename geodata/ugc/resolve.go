@@ -0,0 +1,204 @@
+package ugc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/watchedsky-social/libwatchedsky"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+)
+
+const (
+	selectZoneByID = `SELECT oid FROM zones WHERE id = ? AND retired_at IS NULL`
+	// every zone of a type in a state, for codes like OHZ000
+	selectZonesByIDPrefix = `SELECT oid FROM zones WHERE id LIKE ? || '%' ESCAPE '\' AND retired_at IS NULL ORDER BY oid`
+)
+
+// zoneTypes maps each kind of code to the zone types it can identify. Public, fire and marine
+// zones share the Z namespace, and a public and a fire zone in the same state may have the same
+// number
+var zoneTypes = map[Kind][]string{
+	County: {"county"},
+	Zone:   {"public", "fire", "coastal", "offshore"},
+}
+
+// Resolution maps UGC codes to zone OIDs
+type Resolution struct {
+	// OIDs maps each code that was found to the OIDs of the zones it identifies
+	OIDs map[string][]string
+	// NotFound lists the codes that match no active zone, in the order they were given
+	NotFound []string
+}
+
+// AllOIDs returns the OIDs of every zone that was found, sorted and without duplicates
+func (r *Resolution) AllOIDs() []string {
+	seen := map[string]bool{}
+	var oids []string
+	for _, matched := range r.OIDs {
+		for _, oid := range matched {
+			if !seen[oid] {
+				seen[oid] = true
+				oids = append(oids, oid)
+			}
+		}
+	}
+
+	sort.Strings(oids)
+	return oids
+}
+
+// ResolveOption configures [Resolver.Resolve]
+type ResolveOption func(o *resolveOptions)
+
+type resolveOptions struct {
+	types map[string]bool
+}
+
+// WithZoneTypes limits zone codes to the given zone types. Fire weather products, for example,
+// only refer to fire zones
+func WithZoneTypes(types ...string) ResolveOption {
+	return func(o *resolveOptions) {
+		o.types = map[string]bool{}
+		for _, t := range types {
+			o.types[t] = true
+		}
+	}
+}
+
+// Resolver looks up UGC codes in a geodata DB
+type Resolver struct {
+	db *sql.DB
+}
+
+// NewResolver creates a [Resolver] that queries the zones in s
+func NewResolver(s *geodata.Store) *Resolver {
+	return &Resolver{db: s.DB()}
+}
+
+// Resolve finds the active zones identified by each code by matching zones.id, which ends with
+// the code. A code may match more than one zone unless limited with [WithZoneTypes], and a code
+// for all areas in a state (OHZ000) matches every zone of its kind in the state
+func (r *Resolver) Resolve(ctx context.Context, codes []Code, opts ...ResolveOption) (*Resolution, error) {
+	if ctx == nil {
+		return nil, libwatchedsky.ErrNilContext
+	}
+
+	var o resolveOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	res := &Resolution{OIDs: map[string][]string{}}
+	for _, code := range codes {
+		key := code.String()
+		if _, done := res.OIDs[key]; done {
+			continue
+		}
+
+		var oids []string
+		for _, t := range zoneTypes[code.Kind] {
+			if o.types != nil && !o.types[t] {
+				continue
+			}
+
+			matched, err := r.lookup(ctx, t, code)
+			if err != nil {
+				return nil, err
+			}
+
+			oids = append(oids, matched...)
+		}
+
+		if len(oids) == 0 {
+			res.NotFound = append(res.NotFound, key)
+			continue
+		}
+
+		res.OIDs[key] = oids
+	}
+
+	return res, nil
+}
+
+// ResolveZoneIDs finds the active zones with the given NWS API IDs, such as the affectedZones of an
+// alert from the NWS API, which match zones.id exactly
+func (r *Resolver) ResolveZoneIDs(ctx context.Context, ids []string) (*Resolution, error) {
+	if ctx == nil {
+		return nil, libwatchedsky.ErrNilContext
+	}
+
+	res := &Resolution{OIDs: map[string][]string{}}
+	for _, id := range ids {
+		if _, done := res.OIDs[id]; done {
+			continue
+		}
+
+		var oid string
+		err := r.db.QueryRowContext(ctx, selectZoneByID, id).Scan(&oid)
+		if errors.Is(err, sql.ErrNoRows) {
+			res.NotFound = append(res.NotFound, id)
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		res.OIDs[id] = []string{oid}
+	}
+
+	return res, nil
+}
+
+// ResolveLine parses a UGC line and resolves its codes
+func (r *Resolver) ResolveLine(ctx context.Context, line string, opts ...ResolveOption) (*UGC, *Resolution, error) {
+	u, err := Parse(line)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res, err := r.Resolve(ctx, u.Codes, opts...)
+	return u, res, err
+}
+
+func (r *Resolver) lookup(ctx context.Context, zoneType string, code Code) ([]string, error) {
+	if code.All() {
+		prefix := geodata.NWSAPIZoneID(zoneType, code.State+string(code.Kind))
+		rows, err := r.db.QueryContext(ctx, selectZonesByIDPrefix, escapeLike(prefix))
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		var oids []string
+		for rows.Next() {
+			var oid string
+			if err = rows.Scan(&oid); err != nil {
+				return nil, err
+			}
+			oids = append(oids, oid)
+		}
+
+		return oids, rows.Err()
+	}
+
+	var oid string
+	err := r.db.QueryRowContext(ctx, selectZoneByID, geodata.NWSAPIZoneID(zoneType, code.String())).Scan(&oid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{oid}, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s
+func escapeLike(s string) string {
+	return strings.NewReplacer("%", `\%`, "_", `\_`).Replace(s)
+}
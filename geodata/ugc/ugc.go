@@ -0,0 +1,239 @@
+// Package ugc parses the Universal Geographic Code (UGC) lines that NWS products use to identify
+// the counties and zones they apply to, such as
+//
+//	OHZ003>006-011-OHC035-171515-
+//
+// and resolves the codes to zone OIDs
+package ugc
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the type of area a UGC code identifies
+type Kind byte
+
+const (
+	// County codes identify a county or county equivalent by its FIPS county number
+	County Kind = 'C'
+	// Zone codes identify a public, fire or marine zone
+	Zone Kind = 'Z'
+)
+
+var (
+	// ErrInvalidUGC is returned when a UGC line cannot be parsed
+	ErrInvalidUGC = errors.New("invalid UGC")
+
+	// marineAreas are the two letter areas that take the place of a state in marine zone codes,
+	// e.g. LEZ142 for a zone on Lake Erie. None of them is a state abbreviation
+	marineAreas = map[string]bool{
+		"AM": true, "AN": true, "GM": true, "LC": true, "LE": true, "LH": true, "LM": true, "LO": true,
+		"LS": true, "PH": true, "PK": true, "PM": true, "PS": true, "PZ": true, "SL": true,
+	}
+)
+
+// Code is a single county or zone code, e.g. OHC035. A number of 0 (OHZ000) means every area of
+// that kind in the state
+type Code struct {
+	State  string
+	Kind   Kind
+	Number int
+}
+
+// String returns the code in UGC form, e.g. OHZ003
+func (c Code) String() string {
+	return fmt.Sprintf("%s%c%03d", c.State, c.Kind, c.Number)
+}
+
+// All reports whether the code means every area of its kind in the state
+func (c Code) All() bool {
+	return c.Number == 0
+}
+
+// Marine reports whether the code identifies a coastal or offshore marine zone
+func (c Code) Marine() bool {
+	return c.Kind == Zone && marineAreas[c.State]
+}
+
+// Expiration is the day of the month, hour and minute (UTC) at the end of a UGC line
+type Expiration struct {
+	Day    int
+	Hour   int
+	Minute int
+}
+
+// IsZero reports whether the line had no expiration
+func (e Expiration) IsZero() bool {
+	return e == Expiration{}
+}
+
+// Time returns the first time on or after issued that matches the expiration, rolling over into
+// later months when the day is earlier than the day issued or the month has no such day (a day
+// of 31 issued in April is May 31, not May 1). It returns the zero time for a zero or out of range
+// expiration
+func (e Expiration) Time(issued time.Time) time.Time {
+	if e.Day < 1 || e.Day > 31 {
+		return time.Time{}
+	}
+
+	issued = issued.UTC()
+
+	// every day of the month occurs at least once in any three consecutive months
+	for i := range 3 {
+		t := time.Date(issued.Year(), issued.Month()+time.Month(i), e.Day, e.Hour, e.Minute, 0, 0, time.UTC)
+		if t.Day() != e.Day {
+			// time.Date normalized a day past the end of the month into the next one
+			continue
+		}
+
+		if !t.Before(issued) {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// UGC is a parsed UGC line
+type UGC struct {
+	Codes      []Code
+	Expiration Expiration
+}
+
+// Strings returns every code in UGC form
+func (u *UGC) Strings() []string {
+	codes := make([]string, 0, len(u.Codes))
+	for _, c := range u.Codes {
+		codes = append(codes, c.String())
+	}
+
+	return codes
+}
+
+// Parse parses a UGC line, which may be wrapped over several lines as it is in NWS text products.
+// Ranges (003>006) are expanded, and codes without a state and kind take them from the code
+// before. The expiration is optional
+func Parse(s string) (*UGC, error) {
+	line := strings.Map(func(r rune) rune {
+		if r == '\r' || r == '\n' || r == ' ' || r == '\t' {
+			return -1
+		}
+		return r
+	}, s)
+
+	if line == "" {
+		return nil, fmt.Errorf("%w: empty line", ErrInvalidUGC)
+	}
+
+	u := &UGC{}
+	var (
+		state string
+		kind  Kind
+	)
+
+	groups := strings.Split(strings.TrimSuffix(line, "-"), "-")
+	for i, group := range groups {
+		if isExpiration(group) {
+			if i != len(groups)-1 {
+				return nil, fmt.Errorf("%w: %q: expiration %s is not last", ErrInvalidUGC, s, group)
+			}
+
+			u.Expiration = Expiration{
+				Day:    atoi(group[0:2]),
+				Hour:   atoi(group[2:4]),
+				Minute: atoi(group[4:6]),
+			}
+			break
+		}
+
+		if len(group) >= 3 && isAlpha(group[:2]) {
+			if len(group) < 6 || (group[2] != byte(County) && group[2] != byte(Zone)) {
+				return nil, fmt.Errorf("%w: %q: bad code %s", ErrInvalidUGC, s, group)
+			}
+
+			state, kind = group[:2], Kind(group[2])
+			group = group[3:]
+		}
+
+		if state == "" {
+			return nil, fmt.Errorf("%w: %q: %s has no state", ErrInvalidUGC, s, group)
+		}
+
+		first, last, err := parseRange(group)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %w", ErrInvalidUGC, s, err)
+		}
+
+		for n := first; n <= last; n++ {
+			u.Codes = append(u.Codes, Code{State: state, Kind: kind, Number: n})
+		}
+	}
+
+	if len(u.Codes) == 0 {
+		return nil, fmt.Errorf("%w: %q: no codes", ErrInvalidUGC, s)
+	}
+
+	return u, nil
+}
+
+// parseRange parses NNN or NNN>NNN
+func parseRange(s string) (int, int, error) {
+	from, to, isRange := strings.Cut(s, ">")
+	if !isNumber(from, 3) || (isRange && !isNumber(to, 3)) {
+		return 0, 0, fmt.Errorf("bad number %s", s)
+	}
+
+	first := atoi(from)
+	if !isRange {
+		return first, first, nil
+	}
+
+	last := atoi(to)
+	if last < first {
+		return 0, 0, fmt.Errorf("backwards range %s", s)
+	}
+
+	return first, last, nil
+}
+
+func isExpiration(s string) bool {
+	if !isNumber(s, 6) {
+		return false
+	}
+
+	day, hour, minute := atoi(s[0:2]), atoi(s[2:4]), atoi(s[4:6])
+	return day >= 1 && day <= 31 && hour <= 23 && minute <= 59
+}
+
+func isNumber(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isAlpha(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'A' || s[i] > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
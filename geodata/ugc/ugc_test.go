@@ -0,0 +1,178 @@
+package ugc
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		codes      []string
+		expiration Expiration
+	}{
+		{
+			name:  "single code",
+			line:  "OHC035-",
+			codes: []string{"OHC035"},
+		},
+		{
+			name:       "range and state carried over",
+			line:       "OHZ003>006-011-OHC035-171515-",
+			codes:      []string{"OHZ003", "OHZ004", "OHZ005", "OHZ006", "OHZ011", "OHC035"},
+			expiration: Expiration{Day: 17, Hour: 15, Minute: 15},
+		},
+		{
+			name:  "range of one",
+			line:  "INZ021>021-",
+			codes: []string{"INZ021"},
+		},
+		{
+			name:  "range after a plain number",
+			line:  "LEZ142-144>146-",
+			codes: []string{"LEZ142", "LEZ144", "LEZ145", "LEZ146"},
+		},
+		{
+			name:       "every zone in the state",
+			line:       "OHZ000-010000-",
+			codes:      []string{"OHZ000"},
+			expiration: Expiration{Day: 1},
+		},
+		{
+			name:       "wrapped over lines",
+			line:       "OHZ003>005-\r\nINC001-\n  KYZ089-311200-",
+			codes:      []string{"OHZ003", "OHZ004", "OHZ005", "INC001", "KYZ089"},
+			expiration: Expiration{Day: 31, Hour: 12},
+		},
+		{
+			name:  "no trailing dash",
+			line:  "OHC035-049",
+			codes: []string{"OHC035", "OHC049"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := Parse(tt.line)
+			if err != nil {
+				t.Fatalf("Parse(%q) = %v", tt.line, err)
+			}
+
+			if got := u.Strings(); !slices.Equal(got, tt.codes) {
+				t.Errorf("codes = %v, want %v", got, tt.codes)
+			}
+
+			if u.Expiration != tt.expiration {
+				t.Errorf("expiration = %+v, want %+v", u.Expiration, tt.expiration)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{name: "empty", line: " \n"},
+		{name: "no state", line: "035-"},
+		{name: "bad kind", line: "OHX035-"},
+		{name: "short number", line: "OHC35-"},
+		{name: "backwards range", line: "OHZ006>003-"},
+		{name: "open range", line: "OHZ003>-"},
+		{name: "expiration not last", line: "OHC035-171515-OHC049-"},
+		{name: "only an expiration", line: "171515-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if u, err := Parse(tt.line); !errors.Is(err, ErrInvalidUGC) {
+				t.Errorf("Parse(%q) = %+v, %v, want %v", tt.line, u, err, ErrInvalidUGC)
+			}
+		})
+	}
+}
+
+func TestExpirationTime(t *testing.T) {
+	date := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2025, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		expiration Expiration
+		issued     time.Time
+		want       time.Time
+	}{
+		{
+			name:       "later the same day",
+			expiration: Expiration{Day: 17, Hour: 15, Minute: 15},
+			issued:     date(time.March, 17, 9, 30),
+			want:       date(time.March, 17, 15, 15),
+		},
+		{
+			name:       "exactly when issued",
+			expiration: Expiration{Day: 17, Hour: 9, Minute: 30},
+			issued:     date(time.March, 17, 9, 30),
+			want:       date(time.March, 17, 9, 30),
+		},
+		{
+			name:       "next month",
+			expiration: Expiration{Day: 1, Hour: 6},
+			issued:     date(time.January, 31, 22, 0),
+			want:       date(time.February, 1, 6, 0),
+		},
+		{
+			name:       "next year",
+			expiration: Expiration{Day: 1, Hour: 3},
+			issued:     date(time.December, 31, 21, 0),
+			want:       time.Date(2026, time.January, 1, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "day past the end of the month issued",
+			expiration: Expiration{Day: 31, Hour: 12},
+			issued:     date(time.April, 30, 18, 0),
+			want:       date(time.May, 31, 12, 0),
+		},
+		{
+			name:       "day past the end of the next month",
+			expiration: Expiration{Day: 30, Hour: 12},
+			issued:     date(time.January, 31, 18, 0),
+			want:       date(time.March, 30, 12, 0),
+		},
+		{
+			name:       "issued in another time zone",
+			expiration: Expiration{Day: 18, Hour: 2},
+			issued:     time.Date(2025, time.March, 17, 21, 0, 0, 0, time.FixedZone("EDT", -4*60*60)),
+			want:       date(time.March, 18, 2, 0),
+		},
+		{
+			name:   "zero",
+			issued: date(time.March, 17, 9, 30),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.expiration.Time(tt.issued); !got.Equal(tt.want) {
+				t.Errorf("%+v.Time(%s) = %s, want %s", tt.expiration, tt.issued, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodeMarine(t *testing.T) {
+	for code, want := range map[Code]bool{
+		{State: "LE", Kind: Zone, Number: 142}:  true,
+		{State: "PZ", Kind: Zone, Number: 350}:  true,
+		{State: "OH", Kind: Zone, Number: 55}:   false,
+		{State: "OH", Kind: County, Number: 49}: false,
+	} {
+		if got := code.Marine(); got != want {
+			t.Errorf("%s.Marine() = %t, want %t", code, got, want)
+		}
+	}
+}
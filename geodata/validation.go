@@ -0,0 +1,138 @@
+package geodata
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// DefaultRepairThreshold is the largest relative change in area that repairing an invalid
+// geometry may cause before ingest fails
+const DefaultRepairThreshold = 0.01
+
+var (
+	// ErrRepairThresholdExceeded is returned when making a geometry valid changes its area by more
+	// than the allowed threshold, which usually means the repair dropped or inverted a ring
+	ErrRepairThresholdExceeded = errors.New("geometry repair changed area beyond threshold")
+)
+
+const (
+	// a NULL geometry has nothing to repair
+	validateGeometryQuery = `SELECT COALESCE(ST_IsValid(g), 1) = 1, COALESCE(ST_IsValidReason(g), ''),
+       COALESCE(ST_Area(g), 0), COALESCE(ST_NPoints(g), 0),
+       COALESCE(ST_Area(GeosMakeValid(g)), 0), COALESCE(ST_NPoints(GeosMakeValid(g)), 0)
+FROM (SELECT ST_GeomFromWKB(?, 4326) AS g)`
+	insertValidationResult = `INSERT OR REPLACE INTO geometry_validation
+  (oid, valid, reason, area_before, area_after, vertices_before, vertices_after)
+VALUES (?, ?, ?, ?, ?, ?, ?)`
+	selectValidationResults = `SELECT oid, valid, reason, area_before, area_after, vertices_before, vertices_after
+FROM geometry_validation ORDER BY oid`
+)
+
+// Querier is the subset of [database/sql.DB] and [database/sql.Tx] used to run ingest queries
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// ValidationResult describes how a zone geometry looked before and after it was made valid.
+// Areas are in square degrees, and are only meaningful relative to each other
+type ValidationResult struct {
+	OID            string  `json:"oid"`
+	Valid          bool    `json:"valid"`
+	Reason         string  `json:"reason"`
+	AreaBefore     float64 `json:"areaBefore"`
+	AreaAfter      float64 `json:"areaAfter"`
+	VerticesBefore int     `json:"verticesBefore"`
+	VerticesAfter  int     `json:"verticesAfter"`
+}
+
+// AreaChange returns the change in area caused by the repair, relative to the original area
+func (v *ValidationResult) AreaChange() float64 {
+	if v.AreaBefore == 0 {
+		if v.AreaAfter == 0 {
+			return 0
+		}
+
+		return math.Inf(1)
+	}
+
+	return math.Abs(v.AreaAfter-v.AreaBefore) / v.AreaBefore
+}
+
+// CheckThreshold returns an error wrapping [ErrRepairThresholdExceeded] if the repair changed the
+// area by more than threshold. A threshold of zero or less uses [DefaultRepairThreshold]
+func (v *ValidationResult) CheckThreshold(threshold float64) error {
+	if threshold <= 0 {
+		threshold = DefaultRepairThreshold
+	}
+
+	if change := v.AreaChange(); change > threshold {
+		return fmt.Errorf("%w: %s changed by %.2f%% (%s)", ErrRepairThresholdExceeded, v.OID,
+			change*100, v.Reason)
+	}
+
+	return nil
+}
+
+// ValidateZoneGeometry checks the geometry of z the same way ingest repairs it, with
+// GeosMakeValid. The result can be kept with [RecordValidationResult]
+func ValidateZoneGeometry(ctx context.Context, q Querier, z *Zone) (*ValidationResult, error) {
+	v := &ValidationResult{OID: z.OID()}
+
+	if err := q.QueryRowContext(ctx, validateGeometryQuery, z.Geometry).Scan(&v.Valid, &v.Reason,
+		&v.AreaBefore, &v.VerticesBefore, &v.AreaAfter, &v.VerticesAfter); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// RecordValidationResult replaces the recorded validation result of the zone v describes
+func RecordValidationResult(ctx context.Context, q Querier, v *ValidationResult) error {
+	_, err := q.ExecContext(ctx, insertValidationResult, v.OID, v.Valid, v.Reason, v.AreaBefore,
+		v.AreaAfter, v.VerticesBefore, v.VerticesAfter)
+	return err
+}
+
+// ValidationReport returns the recorded validation result of every zone, ordered by OID
+func (s *Store) ValidationReport(ctx context.Context) ([]ValidationResult, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, selectValidationResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []ValidationResult
+	for rows.Next() {
+		var v ValidationResult
+		if err = rows.Scan(&v.OID, &v.Valid, &v.Reason, &v.AreaBefore, &v.AreaAfter, &v.VerticesBefore,
+			&v.VerticesAfter); err != nil {
+			return nil, err
+		}
+
+		results = append(results, v)
+	}
+
+	return results, rows.Err()
+}
+
+// WriteValidationReport writes results to w as a JSON array
+func WriteValidationReport(w io.Writer, results []ValidationResult) error {
+	if results == nil {
+		results = []ValidationResult{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
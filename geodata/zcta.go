@@ -0,0 +1,78 @@
+package geodata
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/paulmach/orb/geojson"
+	"github.com/watchedsky-social/libwatchedsky/geodata/shapefile"
+)
+
+var (
+	// ErrMissingZCTACode is returned for a ZCTA shapefile record with no recognizable code
+	ErrMissingZCTACode = errors.New("ZCTA record has no code")
+
+	// zctaCodeFields are the attributes that hold the five digit code in the 2020 and 2010
+	// Census ZCTA shapefiles, in order of preference
+	zctaCodeFields = []string{"ZCTA5CE20", "ZCTA5CE10", "GEOID20", "GEOID10"}
+)
+
+// ZCTA is the boundary of a Census ZIP Code Tabulation Area. ZCTAs approximate the area served by
+// a zip code, and share its code
+type ZCTA struct {
+	Code     string
+	Geometry *Geometry
+}
+
+// ZCTAReader reads ZCTA boundaries from a zipped Census TIGER/Line shapefile, such as
+// tl_2020_us_zcta520.zip
+type ZCTAReader struct {
+	r *shapefile.Reader
+}
+
+// OpenZCTAShapefile opens a zipped Census ZCTA shapefile
+func OpenZCTAShapefile(file string) (*ZCTAReader, error) {
+	r, err := shapefile.OpenZip(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ZCTAReader{r: r}, nil
+}
+
+// Next returns the next ZCTA, or [io.EOF] when there are no more
+func (z *ZCTAReader) Next() (*ZCTA, error) {
+	for {
+		f, err := z.r.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		if f.Geometry == nil {
+			continue
+		}
+
+		code, ok := ZCTACode(f)
+		if !ok {
+			return nil, fmt.Errorf("%w: %v", ErrMissingZCTACode, f.Properties)
+		}
+
+		return &ZCTA{Code: code, Geometry: FromOrbGeometry(f.Geometry)}, nil
+	}
+}
+
+// Close closes the underlying shapefile
+func (z *ZCTAReader) Close() error {
+	return z.r.Close()
+}
+
+// ZCTACode returns the five digit code of a feature read from a Census ZCTA shapefile
+func ZCTACode(f *geojson.Feature) (string, bool) {
+	for _, field := range zctaCodeFields {
+		if code, ok := f.Properties[field].(string); ok && len(code) == 5 {
+			return code, true
+		}
+	}
+
+	return "", false
+}
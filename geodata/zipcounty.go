@@ -0,0 +1,256 @@
+package geodata
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DefaultZipCountyDistance is how far, in meters, the center of a zip code may lie outside every
+// county (usually in water) and still be assigned to the nearest one
+const DefaultZipCountyDistance = 5000.0
+
+// The ways a zip code can be assigned to a county in zip_county_pivot
+const (
+	// ZipCountyContains means the county contains the zip code center, or the center lies on a
+	// boundary shared by several counties
+	ZipCountyContains = "contains"
+	// ZipCountyNearest means the center lies outside every county, and this was the nearest one
+	ZipCountyNearest = "nearest"
+	// ZipCountyArea means the ZCTA polygon of the zip code overlaps the county, and the weight is
+	// the fraction of the zip code area inside it
+	ZipCountyArea = "area"
+)
+
+// MinZipCountyWeight is the smallest fraction of a zip code area that a county must cover to be
+// assigned, so that slivers from mismatched boundaries are ignored
+const MinZipCountyWeight = 0.001
+
+const (
+	createAffectedZips = `CREATE TEMP TABLE affected_zips (code TEXT NOT NULL PRIMARY KEY)`
+	insertAllZips      = `INSERT OR IGNORE INTO temp.affected_zips (code) SELECT code FROM us_zip_codes`
+	dropAffectedZips   = `DROP TABLE temp.affected_zips`
+
+	deleteAffectedZipCounties = `DELETE FROM zip_county_pivot WHERE zip_code IN (SELECT code FROM temp.affected_zips)`
+	insertOverlappingCounties = `INSERT OR IGNORE INTO zip_county_pivot (zip_code, county_oid, weight, method)
+SELECT code, oid, weight, '` + ZipCountyArea + `'
+FROM (SELECT zc.code, z.oid, ST_Area(ST_Intersection(z.geometry, zc.area)) / ST_Area(zc.area) AS weight
+      FROM us_zip_codes zc
+             INNER JOIN zones z ON z.type = 'county' AND z.retired_at IS NULL AND z.geometry IS NOT NULL AND
+                                   ST_Intersects(z.geometry, zc.area)
+      WHERE zc.code IN (SELECT code FROM temp.affected_zips) AND zc.area IS NOT NULL AND ST_Area(zc.area) > 0)
+WHERE weight >= ?1`
+	insertContainingCounties = `INSERT OR IGNORE INTO zip_county_pivot (zip_code, county_oid, weight, method)
+SELECT zc.code, z.oid, 1, '` + ZipCountyContains + `'
+FROM us_zip_codes zc
+       INNER JOIN zones z ON z.type = 'county' AND z.retired_at IS NULL AND z.geometry IS NOT NULL AND
+                             ST_Intersects(z.geometry, zc.center)
+WHERE zc.code IN (SELECT code FROM temp.affected_zips)
+  AND NOT EXISTS (SELECT 1 FROM zip_county_pivot p WHERE p.zip_code = zc.code)`
+	insertNearestCounties = `INSERT OR IGNORE INTO zip_county_pivot (zip_code, county_oid, weight, method)
+SELECT code, county_oid, 1, '` + ZipCountyNearest + `'
+FROM (SELECT zc.code,
+             (SELECT z.oid FROM zones z
+              WHERE z.type = 'county' AND z.retired_at IS NULL AND z.geometry IS NOT NULL AND
+                    PtDistWithin(z.geometry, zc.center, ?1, 1)
+              ORDER BY ST_Distance(z.geometry, zc.center, 1)
+              LIMIT 1) AS county_oid
+      FROM us_zip_codes zc
+      WHERE zc.code IN (SELECT code FROM temp.affected_zips)
+        AND NOT EXISTS (SELECT 1 FROM zip_county_pivot p WHERE p.zip_code = zc.code))
+WHERE county_oid IS NOT NULL`
+	// a center on a shared boundary is split evenly between the counties it touches
+	updateZipCountyWeights = `UPDATE zip_county_pivot
+SET weight = 1.0 / (SELECT COUNT(*) FROM zip_county_pivot p
+                    WHERE p.zip_code = zip_county_pivot.zip_code AND p.method = '` + ZipCountyContains + `')
+WHERE zip_code IN (SELECT code FROM temp.affected_zips) AND method = '` + ZipCountyContains + `'`
+	updatePrimaryZipCounties = `UPDATE us_zip_codes
+SET county_oid = (SELECT p.county_oid FROM zip_county_pivot p WHERE p.zip_code = us_zip_codes.code
+                  ORDER BY p.weight DESC, p.method = '` + ZipCountyNearest + `', p.county_oid
+                  LIMIT 1)
+WHERE code IN (SELECT code FROM temp.affected_zips)`
+	// zip codes whose center is in, or whose county is, an affected zone
+	insertZipsOfAffectedZones = `INSERT OR IGNORE INTO temp.affected_zips (code)
+SELECT zc.code
+FROM us_zip_codes zc
+WHERE zc.code IN (SELECT p.zip_code FROM zip_county_pivot p INNER JOIN temp.affected_zones a ON a.oid = p.county_oid)
+   OR NOT EXISTS (SELECT 1 FROM zip_county_pivot p WHERE p.zip_code = zc.code)
+   OR EXISTS (SELECT 1 FROM zones z INNER JOIN temp.affected_zones a ON a.oid = z.oid
+              WHERE z.type = 'county' AND z.retired_at IS NULL AND PtDistWithin(z.geometry, zc.center, ?1, 1))`
+	insertZipAreasOfAffectedZones = `INSERT OR IGNORE INTO temp.affected_zips (code)
+SELECT zc.code
+FROM us_zip_codes zc
+WHERE zc.area IS NOT NULL
+  AND EXISTS (SELECT 1 FROM zones z INNER JOIN temp.affected_zones a ON a.oid = z.oid
+              WHERE z.type = 'county' AND z.retired_at IS NULL AND ST_Intersects(z.geometry, zc.area))`
+
+	// the area column is added by a later migration than zip_county_pivot
+	selectHasZipCodeAreas = `SELECT COUNT(*) > 0 FROM pragma_table_info('us_zip_codes') WHERE name = 'area'`
+
+	selectZipCounties = `SELECT zip_code, county_oid, weight, method FROM zip_county_pivot
+WHERE zip_code = ? ORDER BY weight DESC, county_oid`
+	selectUnassignedZipCodes = `SELECT code, name, state, ST_AsBinary(center) FROM us_zip_codes zc
+WHERE NOT EXISTS (SELECT 1 FROM zip_county_pivot p WHERE p.zip_code = zc.code)
+ORDER BY code`
+)
+
+// ZipCounty is a county that a zip code is assigned to
+type ZipCounty struct {
+	ZipCode   string  `json:"zipCode"`
+	CountyOID string  `json:"countyOid"`
+	Weight    float64 `json:"weight"`
+	Method    string  `json:"method"`
+}
+
+// ZipCode is a row of the us_zip_codes table
+type ZipCode struct {
+	Code   string    `json:"code"`
+	Name   string    `json:"name"`
+	State  string    `json:"state"`
+	Center *Geometry `json:"center"`
+}
+
+// OID returns the watchedsky Object ID of the zip code, e.g. oid:ws:us:oh:zip:44106
+func (zc *ZipCode) OID() string {
+	return ZipCodeOID(zc.State, zc.Code)
+}
+
+// ZipCodeOID returns the watchedsky Object ID of a US zip code. Zip codes use "zip" as their
+// feature type and the code as their short ID
+func ZipCodeOID(state, code string) string {
+	return fmt.Sprintf(oidTemplate, "us", strings.ToLower(state), "zip", code)
+}
+
+// AssignZipCounties rebuilds zip_county_pivot and us_zip_codes.county_oid for every zip code.
+// A zip code with a ZCTA polygon is assigned to every active county covering at least
+// [MinZipCountyWeight] of its area, weighted by that fraction. Any other zip code is assigned to
+// every active county its center touches, with the weight split evenly between them, or failing
+// that to the nearest county within maxDistance meters. A maxDistance of zero or less uses
+// [DefaultZipCountyDistance]. Zip codes that are still unassigned are listed by
+// [Store.UnassignedZipCodes]
+func AssignZipCounties(ctx context.Context, q Querier, maxDistance float64) error {
+	if _, err := q.ExecContext(ctx, createAffectedZips); err != nil {
+		return err
+	}
+
+	if _, err := q.ExecContext(ctx, insertAllZips); err != nil {
+		return err
+	}
+
+	if err := assignAffectedZipCounties(ctx, q, maxDistance); err != nil {
+		return err
+	}
+
+	_, err := q.ExecContext(ctx, dropAffectedZips)
+	return err
+}
+
+func assignAffectedZipCounties(ctx context.Context, q Querier, maxDistance float64) error {
+	if maxDistance <= 0 {
+		maxDistance = DefaultZipCountyDistance
+	}
+
+	hasAreas, err := hasZipCodeAreas(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	type step struct {
+		query string
+		args  []any
+	}
+
+	steps := []step{{deleteAffectedZipCounties, nil}}
+	if hasAreas {
+		steps = append(steps, step{insertOverlappingCounties, []any{MinZipCountyWeight}})
+	}
+
+	steps = append(steps,
+		step{insertContainingCounties, nil},
+		step{insertNearestCounties, []any{maxDistance}},
+		step{updateZipCountyWeights, nil},
+		step{updatePrimaryZipCounties, nil},
+	)
+
+	for _, step := range steps {
+		if _, err = q.ExecContext(ctx, step.query, step.args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hasZipCodeAreas reports whether us_zip_codes has the ZCTA area column
+func hasZipCodeAreas(ctx context.Context, q Querier) (bool, error) {
+	var hasAreas bool
+	err := q.QueryRowContext(ctx, selectHasZipCodeAreas).Scan(&hasAreas)
+	return hasAreas, err
+}
+
+// CountiesForZip returns the counties the zip code is assigned to, heaviest first
+func (s *Store) CountiesForZip(ctx context.Context, code string) ([]ZipCounty, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, selectZipCounties, code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counties []ZipCounty
+	for rows.Next() {
+		var c ZipCounty
+		if err = rows.Scan(&c.ZipCode, &c.CountyOID, &c.Weight, &c.Method); err != nil {
+			return nil, err
+		}
+
+		counties = append(counties, c)
+	}
+
+	return counties, rows.Err()
+}
+
+// UnassignedZipCodes returns every zip code that is not assigned to any county, either because
+// its center is farther than the distance threshold from every county or because no county
+// geometry was loaded for its state
+func (s *Store) UnassignedZipCodes(ctx context.Context) ([]ZipCode, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, selectUnassignedZipCodes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var zips []ZipCode
+	for rows.Next() {
+		zc, err := scanZipCode(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		zips = append(zips, *zc)
+	}
+
+	return zips, rows.Err()
+}
+
+// scanZipCode scans a row of code, name, state and ST_AsBinary(center)
+func scanZipCode(row scanner) (*ZipCode, error) {
+	var (
+		zc     ZipCode
+		center Geometry
+	)
+
+	if err := row.Scan(&zc.Code, &zc.Name, &zc.State, &center); err != nil {
+		return nil, err
+	}
+
+	zc.Center = FromOrbGeometry(center.g)
+	return &zc, nil
+}
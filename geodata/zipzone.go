@@ -0,0 +1,174 @@
+package geodata
+
+import "context"
+
+// DefaultMarineZoneDistance is how far, in meters, a coastal marine zone may be from the area (or
+// center) of a zip code and still be related to it
+const DefaultMarineZoneDistance = 5000.0
+
+// MinZipZoneCoverage is the smallest fraction of a zip code area that a zone must cover to be
+// related to it
+const MinZipZoneCoverage = MinZipCountyWeight
+
+// The ways a zip code can be related to a zone in zip_zone_pivot
+const (
+	// ZipZoneArea means the ZCTA polygon of the zip code overlaps the zone, and the coverage is the
+	// fraction of the zip code area inside it
+	ZipZoneArea = "area"
+	// ZipZoneContains means the zip code has no ZCTA polygon, and the zone contains its center
+	ZipZoneContains = "contains"
+	// ZipZoneAdjacent means the zone is a coastal marine zone near the zip code
+	ZipZoneAdjacent = "adjacent"
+)
+
+const (
+	deleteAffectedZipZones = `DELETE FROM zip_zone_pivot WHERE zip_code IN (SELECT code FROM temp.affected_zips)`
+	insertOverlappingZones = `INSERT OR IGNORE INTO zip_zone_pivot (zip_code, zone_oid, coverage, method)
+SELECT code, oid, coverage, '` + ZipZoneArea + `'
+FROM (SELECT zc.code, z.oid, ST_Area(ST_Intersection(z.geometry, zc.area)) / ST_Area(zc.area) AS coverage
+      FROM us_zip_codes zc
+             INNER JOIN zones z ON z.type != 'county' AND z.retired_at IS NULL AND z.geometry IS NOT NULL AND
+                                   ST_Intersects(z.geometry, zc.area)
+      WHERE zc.code IN (SELECT code FROM temp.affected_zips) AND zc.area IS NOT NULL AND ST_Area(zc.area) > 0)
+WHERE coverage >= ?1`
+	insertContainingZones = `INSERT OR IGNORE INTO zip_zone_pivot (zip_code, zone_oid, coverage, method)
+SELECT zc.code, z.oid, 1, '` + ZipZoneContains + `'
+FROM us_zip_codes zc
+       INNER JOIN zones z ON z.type != 'county' AND z.retired_at IS NULL AND z.geometry IS NOT NULL AND
+                             ST_Intersects(z.geometry, zc.center)
+WHERE zc.code IN (SELECT code FROM temp.affected_zips) AND zc.area IS NULL`
+	insertAdjacentMarineZones = `INSERT OR IGNORE INTO zip_zone_pivot (zip_code, zone_oid, coverage, method)
+SELECT zc.code, z.oid, 0, '` + ZipZoneAdjacent + `'
+FROM us_zip_codes zc
+       INNER JOIN zones z ON z.type = 'coastal' AND z.retired_at IS NULL AND z.geometry IS NOT NULL AND
+                             PtDistWithin(z.geometry, COALESCE(zc.area, zc.center), ?1, 1)
+WHERE zc.code IN (SELECT code FROM temp.affected_zips)`
+	// zip codes related to an affected zone, or whose area or center is near one
+	insertZipsNearAffectedZones = `INSERT OR IGNORE INTO temp.affected_zips (code)
+SELECT zc.code
+FROM us_zip_codes zc
+WHERE zc.code IN (SELECT p.zip_code FROM zip_zone_pivot p INNER JOIN temp.affected_zones a ON a.oid = p.zone_oid)
+   OR EXISTS (SELECT 1 FROM zones z INNER JOIN temp.affected_zones a ON a.oid = z.oid
+              WHERE z.type != 'county' AND z.retired_at IS NULL AND
+                    PtDistWithin(z.geometry, COALESCE(zc.area, zc.center), ?1, 1))`
+
+	selectZonesForZip = `SELECT county_oid FROM zip_county_pivot WHERE zip_code = ?1
+UNION
+SELECT p.zone_oid FROM zip_zone_pivot p INNER JOIN zones z ON z.oid = p.zone_oid
+WHERE p.zip_code = ?1 AND z.retired_at IS NULL
+ORDER BY 1`
+)
+
+// AssignZipZones rebuilds zip_zone_pivot for every zip code. A zip code with a ZCTA polygon is
+// related to every active public, fire or marine zone covering at least [MinZipZoneCoverage] of
+// its area, and any other zip code to the zones containing its center. Coastal marine zones within
+// marineDistance meters are related as well. A marineDistance of zero or less uses
+// [DefaultMarineZoneDistance]
+func AssignZipZones(ctx context.Context, q Querier, marineDistance float64) error {
+	if _, err := q.ExecContext(ctx, createAffectedZips); err != nil {
+		return err
+	}
+
+	if _, err := q.ExecContext(ctx, insertAllZips); err != nil {
+		return err
+	}
+
+	if err := assignAffectedZipZones(ctx, q, marineDistance); err != nil {
+		return err
+	}
+
+	_, err := q.ExecContext(ctx, dropAffectedZips)
+	return err
+}
+
+// reassignZipsOfAffectedZones reassigns the counties and zones of the zip codes that could be
+// affected by a change to any zone in temp.affected_zones, along with any that had no county
+func reassignZipsOfAffectedZones(ctx context.Context, q Querier, countyDistance, marineDistance float64) error {
+	if countyDistance <= 0 {
+		countyDistance = DefaultZipCountyDistance
+	}
+
+	if marineDistance <= 0 {
+		marineDistance = DefaultMarineZoneDistance
+	}
+
+	if _, err := q.ExecContext(ctx, createAffectedZips); err != nil {
+		return err
+	}
+
+	steps := []struct {
+		query string
+		args  []any
+	}{
+		{insertZipsOfAffectedZones, []any{countyDistance}},
+		{insertZipAreasOfAffectedZones, nil},
+		{insertZipsNearAffectedZones, []any{marineDistance}},
+	}
+
+	for _, step := range steps {
+		if _, err := q.ExecContext(ctx, step.query, step.args...); err != nil {
+			return err
+		}
+	}
+
+	if err := assignAffectedZipCounties(ctx, q, countyDistance); err != nil {
+		return err
+	}
+
+	if err := assignAffectedZipZones(ctx, q, marineDistance); err != nil {
+		return err
+	}
+
+	_, err := q.ExecContext(ctx, dropAffectedZips)
+	return err
+}
+
+func assignAffectedZipZones(ctx context.Context, q Querier, marineDistance float64) error {
+	if marineDistance <= 0 {
+		marineDistance = DefaultMarineZoneDistance
+	}
+
+	steps := []struct {
+		query string
+		args  []any
+	}{
+		{deleteAffectedZipZones, nil},
+		{insertOverlappingZones, []any{MinZipZoneCoverage}},
+		{insertContainingZones, nil},
+		{insertAdjacentMarineZones, []any{marineDistance}},
+	}
+
+	for _, step := range steps {
+		if _, err := q.ExecContext(ctx, step.query, step.args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ZonesForZip returns the OIDs of every active zone that covers the zip code: its counties from
+// zip_county_pivot, and the public, fire and nearby marine zones from zip_zone_pivot
+func (s *Store) ZonesForZip(ctx context.Context, code string) ([]string, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, selectZonesForZip, code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var oids []string
+	for rows.Next() {
+		var oid string
+		if err = rows.Scan(&oid); err != nil {
+			return nil, err
+		}
+
+		oids = append(oids, oid)
+	}
+
+	return oids, rows.Err()
+}
@@ -6,6 +6,9 @@ import (
 	"net/url"
 	"path"
 	"strings"
+
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/planar"
 )
 
 // Zone represents a geographic zone in the zones table
@@ -19,6 +22,24 @@ type Zone struct {
 	Geometry *Geometry
 }
 
+// NewZoneFromFeature builds a [Zone] from a GeoJSON feature in the NWS API format, using the
+// centroid of its geometry as the center, and sets its OID for the given country
+func NewZoneFromFeature(country string, f *geojson.Feature) *Zone {
+	centroid, _ := planar.CentroidArea(f.Geometry)
+
+	z := &Zone{
+		ID:       fmt.Sprintf("%v", f.ID),
+		Name:     f.Properties.MustString("name", ""),
+		Type:     f.Properties.MustString("type", "public"),
+		Metadata: JSONB(f.Properties),
+		Center:   FromOrbGeometry(centroid),
+		Geometry: FromOrbGeometry(f.Geometry),
+	}
+	z.SetOID(country)
+
+	return z
+}
+
 // TypeaheadResult are data returned when a user uses typeahead methods
 type TypeaheadResult struct {
 	OID      string `json:"oid"`
@@ -49,11 +70,7 @@ var (
 //	oid:ws:us:oh:county:OHC035
 func (z *Zone) SetOID(country string) {
 	country = strings.ToLower(country)
-	u, err := url.Parse(z.ID)
-	id := z.ID
-	if err == nil {
-		id = path.Base(u.Path)
-	}
+	id := z.ShortID()
 
 	stprov := ""
 	switch country {
@@ -70,6 +87,17 @@ func (z *Zone) SetOID(country string) {
 	z.oid = fmt.Sprintf(oidTemplate, country, strings.ToLower(stprov), strings.ToLower(ftype), id)
 }
 
+// ShortID returns the ID of the zone with any leading URL parts removed, e.g. OHC035 for
+// https://api.weather.gov/zones/county/OHC035
+func (z *Zone) ShortID() string {
+	u, err := url.Parse(z.ID)
+	if err != nil {
+		return z.ID
+	}
+
+	return path.Base(u.Path)
+}
+
 func (z *Zone) OID() string {
 	return z.oid
 }
@@ -0,0 +1,364 @@
+package geodata
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ZoneUpdateOptions controls how [Store.UpdateZones] applies a new source file
+type ZoneUpdateOptions struct {
+	// EffectiveAt is when the new source takes effect, and is recorded as the retirement time of
+	// zones missing from it. It defaults to the current time
+	EffectiveAt time.Time
+	// DryRun computes the report without committing any changes
+	DryRun bool
+	// RepairThreshold is the largest relative change in area that repairing a new or changed
+	// geometry may cause. Zero uses [DefaultRepairThreshold]
+	RepairThreshold float64
+	// Overrides are applied to every zone in the source before it is compared with the DB, and
+	// every override must match a zone
+	Overrides *ZoneOverrides
+	// ZipCountyDistance is how far, in meters, a zip code center may lie outside every county and
+	// still be assigned to the nearest one. Zero uses [DefaultZipCountyDistance]
+	ZipCountyDistance float64
+	// MarineZoneDistance is how far, in meters, a coastal marine zone may be from a zip code and
+	// still be related to it. Zero uses [DefaultMarineZoneDistance]
+	MarineZoneDistance float64
+	// SimplifyZones, if set, is run in the update transaction after the changed zones have been
+	// simplified on their own, so that their neighbors can be simplified to match. Set it to
+	// [github.com/watchedsky-social/libwatchedsky/topology.ZoneSimplifier] to keep simplified
+	// boundaries shared
+	SimplifyZones ZoneSimplifier
+}
+
+// ZoneSimplifier replaces the simplified geometries of the zones in the DB q refers to
+type ZoneSimplifier func(ctx context.Context, q Querier) error
+
+var (
+	// ErrUpdateOutOfOrder is returned by [Store.UpdateZones] when the DB already holds changes
+	// that took effect after the update, since applying it would corrupt the zone history
+	ErrUpdateOutOfOrder = errors.New("zone update is older than changes already applied")
+)
+
+// ZoneUpdateReport lists the OIDs of zones changed by [Store.UpdateZones]
+type ZoneUpdateReport struct {
+	Inserted  []string `json:"inserted"`
+	Updated   []string `json:"updated"`
+	Retired   []string `json:"retired"`
+	Unchanged int      `json:"unchanged"`
+}
+
+// Changed returns the OIDs of every inserted, updated and retired zone
+func (r *ZoneUpdateReport) Changed() []string {
+	changed := make([]string, 0, len(r.Inserted)+len(r.Updated)+len(r.Retired))
+	changed = append(changed, r.Inserted...)
+	changed = append(changed, r.Updated...)
+	return append(changed, r.Retired...)
+}
+
+const (
+	// the NWS publishes some zones without a geometry, so a NULL on either side is only the same
+	// as another NULL
+	selectZoneForUpdate = `SELECT oid, metadata, retired_at IS NOT NULL,
+       COALESCE(ST_AsBinary(geometry) = ST_AsBinary(GeosMakeValid(ST_GeomFromWKB(?1, 4326))),
+                geometry IS NULL AND ?1 IS NULL)
+FROM zones WHERE id = ?2`
+	insertZoneQuery = `INSERT INTO zones (oid, id, name, type, center, geometry, metadata, valid_from)
+VALUES (?, ?, ?, ?, ST_GeomFromWKB(?, 4326), GeosMakeValid(ST_GeomFromWKB(?, 4326)), ?, ?)`
+	updateZoneQuery = `UPDATE zones SET name = ?, type = ?, metadata = ?, center = ST_GeomFromWKB(?, 4326),
+                 geometry = GeosMakeValid(ST_GeomFromWKB(?, 4326)), valid_from = ?, retired_at = NULL
+WHERE oid = ?`
+	selectActiveZones = `SELECT oid, id FROM zones WHERE retired_at IS NULL`
+	retireZoneQuery   = `UPDATE zones SET retired_at = ? WHERE oid = ?`
+
+	// a retired zone that comes back is archived as of its retirement, not the new release
+	archiveZoneQuery = `INSERT INTO zone_history (oid, id, name, type, metadata, valid_from, valid_to, center, geometry)
+SELECT oid, id, name, type, metadata, valid_from, COALESCE(retired_at, ?), center, geometry
+FROM zones WHERE oid = ?`
+	selectLaterChanges = `SELECT EXISTS (SELECT 1 FROM zones WHERE valid_from > ?1 OR retired_at > ?1)
+    OR EXISTS (SELECT 1 FROM zone_history WHERE valid_to > ?1)`
+
+	createAffectedZones = `CREATE TEMP TABLE affected_zones (oid TEXT NOT NULL PRIMARY KEY)`
+	insertAffectedZone  = `INSERT OR IGNORE INTO temp.affected_zones (oid) VALUES (?)`
+	dropAffectedZones   = `DROP TABLE temp.affected_zones`
+
+	deleteAffectedPivotRows = `DELETE FROM zone_county_pivot
+WHERE zone_oid IN (SELECT oid FROM temp.affected_zones) OR county_oid IN (SELECT oid FROM temp.affected_zones)`
+	insertAffectedPivotRows = `INSERT OR IGNORE INTO zone_county_pivot
+  (zone_oid, county_oid, intersection_area, zone_coverage, county_coverage)
+SELECT zone_oid, county_oid, intersection_area, intersection_area / zone_area, intersection_area / county_area
+FROM (SELECT z1.oid AS zone_oid, z2.oid AS county_oid, ST_Area(ST_Intersection(z1.geometry, z2.geometry)) AS intersection_area,
+             ST_Area(z1.geometry) AS zone_area, ST_Area(z2.geometry) AS county_area
+      FROM zones z1
+             INNER JOIN zones z2 ON z1.geometry IS NOT NULL AND z2.geometry IS NOT NULL AND
+                                    ST_Intersects(z1.geometry, z2.geometry)
+      WHERE z1.type != 'county' AND z2.type = 'county'
+        AND z1.retired_at IS NULL AND z2.retired_at IS NULL
+        AND (z1.oid IN (SELECT oid FROM temp.affected_zones) OR z2.oid IN (SELECT oid FROM temp.affected_zones)))
+WHERE intersection_area > 0`
+)
+
+// UpdateZones compares every feature in r against the zones table by ID, inserting new zones,
+// updating the geometry and metadata of changed ones and retiring active zones that are missing
+// from r. The previous version of every updated zone is kept in zone_history (see
+// [Store.ZoneAsOf]), so updates must be applied in the order they took effect. Only the rows of
+// zone_county_pivot, zip_county_pivot, zip_zone_pivot and us_zip_codes.county_oid that involve a
+// changed zone are recomputed, so a new NWS release can be applied without rebuilding the DB. r
+// must contain every zone, since anything absent is retired
+func (s *Store) UpdateZones(ctx context.Context, r FeatureReader, opts ZoneUpdateOptions) (*ZoneUpdateReport, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if opts.EffectiveAt.IsZero() {
+		opts.EffectiveAt = time.Now()
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	effectiveAt := opts.EffectiveAt.UTC()
+
+	var later bool
+	if err = tx.QueryRowContext(ctx, selectLaterChanges, effectiveAt).Scan(&later); err != nil {
+		return nil, err
+	}
+
+	if later {
+		return nil, fmt.Errorf("%w: %s", ErrUpdateOutOfOrder, effectiveAt.Format(time.RFC3339))
+	}
+
+	report, err := applyZoneUpdates(ctx, tx, r, effectiveAt, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+
+	return report, tx.Commit()
+}
+
+func applyZoneUpdates(ctx context.Context, tx *sql.Tx, r FeatureReader, effectiveAt time.Time,
+	opts ZoneUpdateOptions) (*ZoneUpdateReport, error) {
+	selectStmt, err := tx.PrepareContext(ctx, selectZoneForUpdate)
+	if err != nil {
+		return nil, err
+	}
+	defer selectStmt.Close()
+
+	insertStmt, err := tx.PrepareContext(ctx, insertZoneQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer insertStmt.Close()
+
+	updateStmt, err := tx.PrepareContext(ctx, updateZoneQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer updateStmt.Close()
+
+	archiveStmt, err := tx.PrepareContext(ctx, archiveZoneQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer archiveStmt.Close()
+
+	report := &ZoneUpdateReport{}
+	seen := map[string]bool{}
+
+	for {
+		f, err := r.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, err
+		}
+
+		z := NewZoneFromFeature("us", f)
+		applied := opts.Overrides.Apply("us", z)
+		seen[z.ID] = true
+
+		newMetadata, err := json.Marshal(z.Metadata)
+		if err != nil {
+			return nil, err
+		}
+
+		var (
+			oid           string
+			metadata      []byte
+			retired, same bool
+		)
+
+		err = selectStmt.QueryRowContext(ctx, z.Geometry, z.ID).Scan(&oid, &metadata, &retired, &same)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+
+		if !errors.Is(err, sql.ErrNoRows) && !retired && same && bytes.Equal(metadata, newMetadata) {
+			report.Unchanged++
+			continue
+		}
+
+		if oid != "" {
+			z.oid = oid
+			for i := range applied {
+				applied[i].OID = oid
+			}
+		}
+
+		if err = RecordZoneOverrides(ctx, tx, applied, effectiveAt); err != nil {
+			return nil, err
+		}
+
+		v, err := ValidateZoneGeometry(ctx, tx, z)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = v.CheckThreshold(opts.RepairThreshold); err != nil {
+			return nil, err
+		}
+
+		if err = RecordValidationResult(ctx, tx, v); err != nil {
+			return nil, err
+		}
+
+		// the OID is the key other tables refer to, so an existing zone keeps its OID even if
+		// the state or type it was derived from has changed
+		if oid == "" {
+			if _, err = insertStmt.ExecContext(ctx, z.OID(), z.ID, z.Name, z.Type, z.Center, z.Geometry,
+				z.Metadata, effectiveAt); err != nil {
+				return nil, err
+			}
+
+			if err = setZoneFIPS(ctx, tx, z); err != nil {
+				return nil, err
+			}
+
+			report.Inserted = append(report.Inserted, z.OID())
+			continue
+		}
+
+		if _, err = archiveStmt.ExecContext(ctx, effectiveAt, oid); err != nil {
+			return nil, err
+		}
+
+		if _, err = updateStmt.ExecContext(ctx, z.Name, z.Type, z.Metadata, z.Center, z.Geometry,
+			effectiveAt, oid); err != nil {
+			return nil, err
+		}
+
+		if err = setZoneFIPS(ctx, tx, z); err != nil {
+			return nil, err
+		}
+
+		report.Updated = append(report.Updated, oid)
+	}
+
+	if err = opts.Overrides.Check(); err != nil {
+		return nil, err
+	}
+
+	retired, err := retireMissingZones(ctx, tx, seen, effectiveAt)
+	if err != nil {
+		return nil, err
+	}
+	report.Retired = retired
+
+	if err = recomputeZoneRelations(ctx, tx, report.Changed(), opts); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func retireMissingZones(ctx context.Context, tx *sql.Tx, seen map[string]bool, effectiveAt time.Time) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, selectActiveZones)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for rows.Next() {
+		var oid, id string
+		if err = rows.Scan(&oid, &id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		if !seen[id] {
+			missing = append(missing, oid)
+		}
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, oid := range missing {
+		if _, err = tx.ExecContext(ctx, retireZoneQuery, effectiveAt, oid); err != nil {
+			return nil, err
+		}
+	}
+
+	return missing, nil
+}
+
+// recomputeZoneRelations rebuilds the zone and zip code pivot rows that involve any of oids
+func recomputeZoneRelations(ctx context.Context, tx *sql.Tx, oids []string, opts ZoneUpdateOptions) error {
+	if len(oids) == 0 {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, createAffectedZones); err != nil {
+		return err
+	}
+
+	for _, oid := range oids {
+		if _, err := tx.ExecContext(ctx, insertAffectedZone, oid); err != nil {
+			return err
+		}
+	}
+
+	for _, query := range []string{deleteAffectedPivotRows, insertAffectedPivotRows} {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+
+	if err := reassignZipsOfAffectedZones(ctx, tx, opts.ZipCountyDistance, opts.MarineZoneDistance); err != nil {
+		return err
+	}
+
+	if err := assignAffectedZoneTimeZones(ctx, tx); err != nil {
+		return err
+	}
+
+	if err := simplifyAffectedZones(ctx, tx); err != nil {
+		return err
+	}
+
+	if opts.SimplifyZones != nil {
+		if err := opts.SimplifyZones(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.ExecContext(ctx, dropAffectedZones)
+	return err
+}
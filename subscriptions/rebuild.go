@@ -0,0 +1,113 @@
+package subscriptions
+
+import (
+	"context"
+
+	"github.com/watchedsky-social/libwatchedsky"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+)
+
+const (
+	selectZoneCountyRelations = `SELECT p.zone_oid, p.county_oid
+FROM zone_county_pivot p
+       INNER JOIN zones z ON z.oid = p.zone_oid
+       INNER JOIN zones c ON c.oid = p.county_oid
+WHERE z.retired_at IS NULL AND c.retired_at IS NULL
+  AND (p.zone_coverage >= ?1 OR p.county_coverage >= ?1)`
+	selectZipCountyRelations = `SELECT p.county_oid, zc.state, zc.code
+FROM zip_county_pivot p
+       INNER JOIN us_zip_codes zc ON zc.code = p.zip_code
+       INNER JOIN zones c ON c.oid = p.county_oid
+WHERE c.retired_at IS NULL AND p.weight >= ?1`
+	selectZipZoneRelations = `SELECT p.zone_oid, zc.state, zc.code
+FROM zip_zone_pivot p
+       INNER JOIN us_zip_codes zc ON zc.code = p.zip_code
+       INNER JOIN zones z ON z.oid = p.zone_oid
+WHERE z.retired_at IS NULL AND p.method != '` + geodata.ZipZoneAdjacent + `' AND p.coverage >= ?1`
+)
+
+// RebuildOption configures [Index.Rebuild]
+type RebuildOption func(o *rebuildOptions)
+
+type rebuildOptions struct {
+	minCoverage float64
+}
+
+// WithMinCoverage leaves out zone and county pairs, and zip codes, whose overlap is less than
+// fraction of the area of either. Zone and county boundaries are drawn slightly differently, so a
+// small threshold keeps slivers from notifying a whole neighboring county
+func WithMinCoverage(fraction float64) RebuildOption {
+	return func(o *rebuildOptions) {
+		o.minCoverage = fraction
+	}
+}
+
+// Rebuild replaces the relations between OIDs with those in zone_county_pivot, zip_county_pivot
+// and zip_zone_pivot. An affected zone notifies the subscribers of the counties it overlaps and
+// the zip codes in it, and an affected county notifies the subscribers of the zones that overlap
+// it and the zip codes in it. Subscriptions are kept
+func (idx *Index) Rebuild(ctx context.Context, s *geodata.Store, opts ...RebuildOption) error {
+	if ctx == nil {
+		return libwatchedsky.ErrNilContext
+	}
+
+	var o rebuildOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	related := map[string][]relation{}
+	db := s.DB()
+
+	rows, err := db.QueryContext(ctx, selectZoneCountyRelations, o.minCoverage)
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		var zone, county string
+		if err = rows.Scan(&zone, &county); err != nil {
+			rows.Close()
+			return err
+		}
+
+		related[zone] = append(related[zone], relation{oid: county, reason: ReasonOverlap})
+		related[county] = append(related[county], relation{oid: zone, reason: ReasonOverlap})
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, q := range []struct {
+		query string
+		args  []any
+	}{
+		{selectZipCountyRelations, []any{o.minCoverage}},
+		{selectZipZoneRelations, []any{o.minCoverage}},
+	} {
+		rows, err := db.QueryContext(ctx, q.query, q.args...)
+		if err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			var oid, state, code string
+			if err = rows.Scan(&oid, &state, &code); err != nil {
+				rows.Close()
+				return err
+			}
+
+			related[oid] = append(related[oid], relation{oid: geodata.ZipCodeOID(state, code), reason: ReasonContains})
+		}
+		rows.Close()
+
+		if err = rows.Err(); err != nil {
+			return err
+		}
+	}
+
+	idx.setRelations(related)
+	return nil
+}
@@ -0,0 +1,145 @@
+// Package subscriptions matches the zones affected by an alert to the users subscribed to them.
+// Users subscribe to any OID (a zone, a county or a zip code), and an in-memory index built from
+// the geodata pivot tables relates each OID to the ones that overlap it
+package subscriptions
+
+import (
+	"sort"
+	"sync"
+)
+
+// Reason is why a subscriber matched an alert
+type Reason string
+
+const (
+	// ReasonDirect means the subscribed OID is one of the affected OIDs
+	ReasonDirect Reason = "direct"
+	// ReasonOverlap means the subscribed zone or county overlaps an affected county or zone
+	ReasonOverlap Reason = "overlap"
+	// ReasonContains means the subscribed zip code is in an affected county or zone
+	ReasonContains Reason = "contains"
+)
+
+// rank orders reasons from the most to the least specific, so a subscriber who matches more than
+// one way is reported with the best reason
+var rank = map[Reason]int{
+	ReasonDirect:   0,
+	ReasonContains: 1,
+	ReasonOverlap:  2,
+}
+
+// Match is a subscriber to notify about an alert
+type Match struct {
+	SubscriberID string `json:"subscriberId"`
+	// OID is the OID the subscriber subscribed to
+	OID string `json:"oid"`
+	// AffectedOID is the affected OID that the subscription matched
+	AffectedOID string `json:"affectedOid"`
+	Reason      Reason `json:"reason"`
+}
+
+// relation is an OID subscribers should be notified through when another OID is affected
+type relation struct {
+	oid    string
+	reason Reason
+}
+
+// Index holds subscriptions and the relations between OIDs. It is safe for concurrent use
+type Index struct {
+	mu sync.RWMutex
+
+	// subscribers maps each OID to the IDs of the subscribers to it
+	subscribers map[string]map[string]bool
+	// related maps an affected OID to the other OIDs whose subscribers should be notified
+	related map[string][]relation
+}
+
+// NewIndex creates an empty [Index]. Until relations are loaded with [Index.Rebuild], only direct
+// subscriptions match
+func NewIndex() *Index {
+	return &Index{
+		subscribers: map[string]map[string]bool{},
+		related:     map[string][]relation{},
+	}
+}
+
+// Subscribe subscribes a subscriber to an OID
+func (idx *Index) Subscribe(subscriberID, oid string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if idx.subscribers[oid] == nil {
+		idx.subscribers[oid] = map[string]bool{}
+	}
+	idx.subscribers[oid][subscriberID] = true
+}
+
+// Unsubscribe removes a subscription. Removing a subscription that does not exist does nothing
+func (idx *Index) Unsubscribe(subscriberID, oid string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	delete(idx.subscribers[oid], subscriberID)
+	if len(idx.subscribers[oid]) == 0 {
+		delete(idx.subscribers, oid)
+	}
+}
+
+// Subscriptions returns the OIDs a subscriber is subscribed to, sorted
+func (idx *Index) Subscriptions(subscriberID string) []string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var oids []string
+	for oid, subs := range idx.subscribers {
+		if subs[subscriberID] {
+			oids = append(oids, oid)
+		}
+	}
+
+	sort.Strings(oids)
+	return oids
+}
+
+// Match returns one [Match] per subscriber that should be notified about an alert affecting the
+// given OIDs, with the most specific reason they matched, ordered by subscriber ID
+func (idx *Index) Match(affected []string) []Match {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	best := map[string]Match{}
+	consider := func(oid, affectedOID string, reason Reason) {
+		for sub := range idx.subscribers[oid] {
+			m, ok := best[sub]
+			if !ok || rank[reason] < rank[m.Reason] {
+				best[sub] = Match{SubscriberID: sub, OID: oid, AffectedOID: affectedOID, Reason: reason}
+			}
+		}
+	}
+
+	for _, oid := range affected {
+		consider(oid, oid, ReasonDirect)
+		for _, rel := range idx.related[oid] {
+			consider(rel.oid, oid, rel.reason)
+		}
+	}
+
+	matches := make([]Match, 0, len(best))
+	for _, m := range best {
+		matches = append(matches, m)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].SubscriberID < matches[j].SubscriberID
+	})
+
+	return matches
+}
+
+// setRelations replaces the relations between OIDs
+func (idx *Index) setRelations(related map[string][]relation) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.related = related
+}
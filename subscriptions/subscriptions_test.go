@@ -0,0 +1,108 @@
+package subscriptions
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+)
+
+func TestMatch(t *testing.T) {
+	zip := geodata.ZipCodeOID("OH", "45202")
+
+	idx := NewIndex()
+	idx.setRelations(map[string][]relation{
+		"county-61": {{oid: "zone-77", reason: ReasonOverlap}, {oid: zip, reason: ReasonContains}},
+		"zone-77":   {{oid: "county-61", reason: ReasonOverlap}, {oid: zip, reason: ReasonContains}},
+	})
+
+	for _, sub := range []struct{ id, oid string }{
+		{"alice", "zone-77"},
+		{"bob", "county-61"},
+		{"carol", zip},
+		{"dave", "zone-77"},
+		{"dave", "county-61"},
+		{"erin", "county-49"},
+	} {
+		idx.Subscribe(sub.id, sub.oid)
+	}
+
+	tests := []struct {
+		name     string
+		affected []string
+		want     []Match
+	}{
+		{
+			name:     "direct OID",
+			affected: []string{"county-49"},
+			want:     []Match{{SubscriberID: "erin", OID: "county-49", AffectedOID: "county-49", Reason: ReasonDirect}},
+		},
+		{
+			name:     "county overlapping a zone",
+			affected: []string{"county-61"},
+			want: []Match{
+				{SubscriberID: "alice", OID: "zone-77", AffectedOID: "county-61", Reason: ReasonOverlap},
+				{SubscriberID: "bob", OID: "county-61", AffectedOID: "county-61", Reason: ReasonDirect},
+				{SubscriberID: "carol", OID: zip, AffectedOID: "county-61", Reason: ReasonContains},
+				{SubscriberID: "dave", OID: "county-61", AffectedOID: "county-61", Reason: ReasonDirect},
+			},
+		},
+		{
+			name:     "zip code in a zone",
+			affected: []string{"zone-77"},
+			want: []Match{
+				{SubscriberID: "alice", OID: "zone-77", AffectedOID: "zone-77", Reason: ReasonDirect},
+				{SubscriberID: "bob", OID: "county-61", AffectedOID: "zone-77", Reason: ReasonOverlap},
+				{SubscriberID: "carol", OID: zip, AffectedOID: "zone-77", Reason: ReasonContains},
+				{SubscriberID: "dave", OID: "zone-77", AffectedOID: "zone-77", Reason: ReasonDirect},
+			},
+		},
+		{
+			// bob overlaps through the zone before matching the county directly, and carol's zip
+			// code matches through both without a better reason
+			name:     "duplicate subscribers keep their best reason",
+			affected: []string{"zone-77", "county-61"},
+			want: []Match{
+				{SubscriberID: "alice", OID: "zone-77", AffectedOID: "zone-77", Reason: ReasonDirect},
+				{SubscriberID: "bob", OID: "county-61", AffectedOID: "county-61", Reason: ReasonDirect},
+				{SubscriberID: "carol", OID: zip, AffectedOID: "zone-77", Reason: ReasonContains},
+				{SubscriberID: "dave", OID: "zone-77", AffectedOID: "zone-77", Reason: ReasonDirect},
+			},
+		},
+		{
+			name:     "no subscribers",
+			affected: []string{"county-35"},
+			want:     []Match{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := idx.Match(tt.affected); !slices.Equal(got, tt.want) {
+				t.Errorf("Match(%v) = %+v, want %+v", tt.affected, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscriptions(t *testing.T) {
+	idx := NewIndex()
+	idx.Subscribe("alice", "zone-77")
+	idx.Subscribe("alice", "county-61")
+	idx.Subscribe("bob", "county-61")
+
+	if got := idx.Subscriptions("alice"); !slices.Equal(got, []string{"county-61", "zone-77"}) {
+		t.Errorf("Subscriptions() = %v, want both OIDs sorted", got)
+	}
+
+	idx.Unsubscribe("alice", "county-61")
+	idx.Unsubscribe("alice", "county-49")
+
+	if got := idx.Subscriptions("alice"); !slices.Equal(got, []string{"zone-77"}) {
+		t.Errorf("Subscriptions() after unsubscribing = %v, want [zone-77]", got)
+	}
+
+	if got := idx.Match([]string{"county-61"}); len(got) != 1 || got[0].SubscriberID != "bob" {
+		t.Errorf("Match() = %+v, want only bob", got)
+	}
+}
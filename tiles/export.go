@@ -0,0 +1,232 @@
+package tiles
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/maptile"
+	"github.com/watchedsky-social/libwatchedsky"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+)
+
+const (
+	// DefaultMinZoom is the lowest zoom exported by [ExportPMTiles]
+	DefaultMinZoom = 0
+	// DefaultMaxZoom is the highest zoom exported by [ExportPMTiles]. Clients overzoom past it,
+	// which suits zone outlines well
+	DefaultMaxZoom = 10
+)
+
+const (
+	selectZonesExtent = `SELECT MIN(MbrMinX(geometry)), MIN(MbrMinY(geometry)), MAX(MbrMaxX(geometry)), MAX(MbrMaxY(geometry))
+FROM zones
+WHERE retired_at IS NULL AND geometry IS NOT NULL`
+)
+
+// ExportOption configures [ExportPMTiles]
+type ExportOption func(o *exportOptions)
+
+type exportOptions struct {
+	minZoom  uint8
+	maxZoom  uint8
+	name     string
+	tileOpts []Option
+}
+
+// WithZoomRange sets the zooms that are exported. The defaults are [DefaultMinZoom] and
+// [DefaultMaxZoom]
+func WithZoomRange(lo, hi uint8) ExportOption {
+	return func(o *exportOptions) {
+		if lo <= hi && hi <= maxZoom {
+			o.minZoom, o.maxZoom = lo, hi
+		}
+	}
+}
+
+// WithName sets the name in the archive metadata
+func WithName(name string) ExportOption {
+	return func(o *exportOptions) {
+		o.name = name
+	}
+}
+
+// WithTileOptions sets the [Option]s tiles are encoded with. Tiles in an archive are always
+// gzipped
+func WithTileOptions(opts ...Option) ExportOption {
+	return func(o *exportOptions) {
+		o.tileOpts = opts
+	}
+}
+
+// ExportPMTilesFile writes the zone layers to a PMTiles archive at file, which can be served
+// statically from any host that supports HTTP range requests. The archive is written to a
+// temporary file in the same directory and renamed over file once it is complete, so a failed
+// export never leaves a partial archive behind
+func ExportPMTilesFile(ctx context.Context, store *geodata.Store, file string, opts ...ExportOption) error {
+	f, err := os.CreateTemp(filepath.Dir(file), ".tmp-"+filepath.Base(file))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	// CreateTemp makes the file private, but the archive is meant to be served
+	if err = f.Chmod(0o644); err == nil {
+		err = ExportPMTiles(ctx, store, f, opts...)
+	}
+
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), file)
+}
+
+// ExportPMTiles writes the zone layers, as rendered by [Encoder], to w as a PMTiles v3 archive.
+// Each zoom covers the extent of the active zones, and a tile is only rendered if its parent had
+// zones in it, so open ocean is skipped below the lowest zoom. Identical tiles are stored once
+func ExportPMTiles(ctx context.Context, store *geodata.Store, w io.Writer, opts ...ExportOption) error {
+	if ctx == nil {
+		return libwatchedsky.ErrNilContext
+	}
+
+	o := exportOptions{minZoom: DefaultMinZoom, maxZoom: DefaultMaxZoom, name: "zones"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	bounds, err := zonesExtent(ctx, store)
+	if err != nil {
+		return err
+	}
+
+	pw, err := newPMTilesWriter()
+	if err != nil {
+		return err
+	}
+	defer pw.Close()
+
+	e := NewEncoder(store, o.tileOpts...)
+	layerNames := map[string]bool{}
+
+	candidates := tilesInBound(bounds, o.minZoom)
+	for z := o.minZoom; z <= o.maxZoom && len(candidates) > 0; z++ {
+		sortByTileID(z, candidates)
+
+		var next [][2]uint32
+		for _, c := range candidates {
+			layers, found, err := e.layers(ctx, maptile.New(c[0], c[1], maptile.Zoom(z)))
+			if err != nil {
+				return fmt.Errorf("%d/%d/%d: %w", z, c[0], c[1], err)
+			}
+
+			if !found {
+				continue
+			}
+
+			for _, child := range maptile.New(c[0], c[1], maptile.Zoom(z)).Children() {
+				next = append(next, [2]uint32{child.X, child.Y})
+			}
+
+			if len(layers) == 0 {
+				continue
+			}
+
+			for _, l := range layers {
+				layerNames[l.Name] = true
+			}
+
+			tile, err := mvt.MarshalGzipped(layers)
+			if err != nil {
+				return fmt.Errorf("%d/%d/%d: %w", z, c[0], c[1], err)
+			}
+
+			if err = pw.Add(z, c[0], c[1], tile); err != nil {
+				return err
+			}
+		}
+
+		candidates = next
+	}
+
+	return pw.WriteTo(w, bounds, archiveMetadata(o, layerNames))
+}
+
+// zonesExtent returns the bounding box of every active zone
+func zonesExtent(ctx context.Context, store *geodata.Store) (orb.Bound, error) {
+	var minX, minY, maxX, maxY sql.NullFloat64
+	if err := store.DB().QueryRowContext(ctx, selectZonesExtent).Scan(&minX, &minY, &maxX, &maxY); err != nil {
+		return orb.Bound{}, err
+	}
+
+	if !minX.Valid {
+		return orb.Bound{}, nil
+	}
+
+	return orb.Bound{
+		Min: orb.Point{minX.Float64, minY.Float64},
+		Max: orb.Point{maxX.Float64, maxY.Float64},
+	}, nil
+}
+
+// tilesInBound returns the x and y of every tile at zoom z that covers part of bound
+func tilesInBound(bound orb.Bound, z uint8) [][2]uint32 {
+	minTile := maptile.At(orb.Point{bound.Min.Lon(), bound.Max.Lat()}, maptile.Zoom(z))
+	maxTile := maptile.At(orb.Point{bound.Max.Lon(), bound.Min.Lat()}, maptile.Zoom(z))
+
+	// a bound on the antimeridian or a pole falls just past the last tile
+	last := uint32(1)<<z - 1
+
+	var tiles [][2]uint32
+	for x := minTile.X; x <= min(maxTile.X, last); x++ {
+		for y := minTile.Y; y <= min(maxTile.Y, last); y++ {
+			tiles = append(tiles, [2]uint32{x, y})
+		}
+	}
+
+	return tiles
+}
+
+// vectorLayer describes a layer in the metadata of an archive, as map clients expect
+type vectorLayer struct {
+	ID      string            `json:"id"`
+	Fields  map[string]string `json:"fields"`
+	MinZoom uint8             `json:"minzoom"`
+	MaxZoom uint8             `json:"maxzoom"`
+}
+
+func archiveMetadata(o exportOptions, layerNames map[string]bool) map[string]any {
+	names := make([]string, 0, len(layerNames))
+	for name := range layerNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	layers := make([]vectorLayer, 0, len(names))
+	for _, name := range names {
+		layers = append(layers, vectorLayer{
+			ID:      name,
+			Fields:  map[string]string{"oid": "String", "name": "String"},
+			MinZoom: o.minZoom,
+			MaxZoom: o.maxZoom,
+		})
+	}
+
+	return map[string]any{
+		"name":          o.name,
+		"format":        "pbf",
+		"type":          "overlay",
+		"vector_layers": layers,
+	}
+}
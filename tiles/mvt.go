@@ -0,0 +1,289 @@
+// Package tiles renders geodata zones as Mapbox Vector Tiles, so that map clients can draw zone
+// outlines without downloading their full GeoJSON
+package tiles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/maptile"
+	"github.com/paulmach/orb/simplify"
+	"github.com/watchedsky-social/libwatchedsky"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+)
+
+// ContentType is the media type of an uncompressed vector tile
+const ContentType = "application/vnd.mapbox-vector-tile"
+
+const (
+	// DefaultBuffer is how far, in tile units, geometries extend past the edge of a tile so that
+	// outlines are not drawn along tile boundaries
+	DefaultBuffer = 64
+	// DefaultTolerance is the Douglas-Peucker tolerance, in tile units, that geometries are
+	// simplified with. Tile units are the same size on screen at every zoom, so one tolerance
+	// suits them all
+	DefaultTolerance = 1.0
+
+	// maxZoom is the deepest zoom whose tile x and y fit in a uint32
+	maxZoom = 31
+)
+
+const (
+	// the spatial index narrows candidates to zones whose bounding box meets the buffered tile.
+	// Geometries are read at the resolution chosen for the zoom
+	selectZonesInBound = `SELECT oid, name, type, ST_AsBinary(%s)
+FROM zones
+WHERE retired_at IS NULL AND geometry IS NOT NULL
+  AND ROWID IN (SELECT ROWID FROM SpatialIndex
+                WHERE f_table_name = 'zones' AND f_geometry_column = 'geometry'
+                  AND search_frame = BuildMbr(?1, ?2, ?3, ?4, 4326))
+  AND ST_Intersects(geometry, BuildMbr(?1, ?2, ?3, ?4, 4326))`
+)
+
+var (
+	// ErrInvalidTile is returned when a tile's x or y is outside the range of its zoom
+	ErrInvalidTile = errors.New("invalid tile")
+)
+
+// Option configures an [Encoder]
+type Option func(o *options)
+
+type options struct {
+	extent    uint32
+	buffer    float64
+	tolerance float64
+	types     map[string]bool
+	gzip      bool
+}
+
+// WithExtent sets the number of tile units across a tile. The default is [mvt.DefaultExtent]
+func WithExtent(extent uint32) Option {
+	return func(o *options) {
+		if extent > 0 {
+			o.extent = extent
+		}
+	}
+}
+
+// WithBuffer sets how far, in tile units, geometries extend past the edge of a tile. The default
+// is [DefaultBuffer]
+func WithBuffer(buffer float64) Option {
+	return func(o *options) {
+		if buffer >= 0 {
+			o.buffer = buffer
+		}
+	}
+}
+
+// WithTolerance sets the simplification tolerance in tile units. Zero turns simplification off.
+// The default is [DefaultTolerance]
+func WithTolerance(tolerance float64) Option {
+	return func(o *options) {
+		if tolerance >= 0 {
+			o.tolerance = tolerance
+		}
+	}
+}
+
+// WithZoneTypes limits tiles to the given zone types, such as "county" or "public". By default
+// every type is included
+func WithZoneTypes(types ...string) Option {
+	return func(o *options) {
+		o.types = make(map[string]bool, len(types))
+		for _, t := range types {
+			o.types[t] = true
+		}
+	}
+}
+
+// WithGzip gzips encoded tiles, for handlers that serve them with Content-Encoding: gzip
+func WithGzip() Option {
+	return func(o *options) {
+		o.gzip = true
+	}
+}
+
+// Encoder renders zones from a geodata DB as vector tiles. Each zone type is its own layer, named
+// after the type, and each feature has the oid and name of its zone as properties. An Encoder is
+// safe for concurrent use
+type Encoder struct {
+	store *geodata.Store
+	o     options
+}
+
+// NewEncoder creates an [Encoder] that reads zones from store
+func NewEncoder(store *geodata.Store, opts ...Option) *Encoder {
+	o := options{
+		extent:    mvt.DefaultExtent,
+		buffer:    DefaultBuffer,
+		tolerance: DefaultTolerance,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Encoder{store: store, o: o}
+}
+
+// Tile encodes the tile at z/x/y. A tile with no zones in it encodes to an empty slice, which is
+// a valid tile. This is meant to be called from an HTTP handler, which should serve the result
+// with [ContentType]
+func (e *Encoder) Tile(ctx context.Context, z, x, y uint32) ([]byte, error) {
+	layers, err := e.Layers(ctx, z, x, y)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.o.gzip {
+		return mvt.MarshalGzipped(layers)
+	}
+
+	return mvt.Marshal(layers)
+}
+
+// Layers returns the layers of the tile at z/x/y, projected, clipped and simplified but not yet
+// encoded
+func (e *Encoder) Layers(ctx context.Context, z, x, y uint32) (mvt.Layers, error) {
+	if ctx == nil {
+		return nil, libwatchedsky.ErrNilContext
+	}
+
+	tile, err := newTile(z, x, y)
+	if err != nil {
+		return nil, err
+	}
+
+	layers, _, err := e.layers(ctx, tile)
+	return layers, err
+}
+
+// layers builds the layers of tile, and reports whether any zone met the buffered tile before
+// small features were removed
+func (e *Encoder) layers(ctx context.Context, tile maptile.Tile) (mvt.Layers, bool, error) {
+	byType, err := e.features(ctx, tile)
+	if err != nil {
+		return nil, false, err
+	}
+
+	types := make([]string, 0, len(byType))
+	for t := range byType {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	layers := make(mvt.Layers, 0, len(types))
+	for _, t := range types {
+		l := mvt.NewLayer(t, byType[t])
+		l.Extent = e.o.extent
+		layers = append(layers, l)
+	}
+
+	layers.ProjectToTile(tile)
+	layers.Clip(orb.Bound{
+		Min: orb.Point{-e.o.buffer, -e.o.buffer},
+		Max: orb.Point{float64(e.o.extent) + e.o.buffer, float64(e.o.extent) + e.o.buffer},
+	})
+
+	if e.o.tolerance > 0 {
+		layers.Simplify(simplify.DouglasPeucker(e.o.tolerance))
+	}
+	layers.RemoveEmpty(1, 1)
+
+	nonEmpty := layers[:0]
+	for _, l := range layers {
+		if len(l.Features) > 0 {
+			nonEmpty = append(nonEmpty, l)
+		}
+	}
+
+	return nonEmpty, len(byType) > 0, nil
+}
+
+// features returns the zones that meet the buffered tile, grouped by type
+func (e *Encoder) features(ctx context.Context, tile maptile.Tile) (map[string]*geojson.FeatureCollection, error) {
+	bound := bufferedBound(tile, e.o.extent, e.o.buffer)
+
+	query := fmt.Sprintf(selectZonesInBound, e.resolution(tile.Z).GeometryExpr())
+	rows, err := e.store.DB().QueryContext(ctx, query,
+		bound.Min.Lon(), bound.Min.Lat(), bound.Max.Lon(), bound.Max.Lat())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byType := map[string]*geojson.FeatureCollection{}
+	for rows.Next() {
+		var (
+			oid, name, zoneType string
+			geometry            geodata.Geometry
+		)
+
+		if err = rows.Scan(&oid, &name, &zoneType, &geometry); err != nil {
+			return nil, err
+		}
+
+		if e.o.types != nil && !e.o.types[zoneType] {
+			continue
+		}
+
+		g := geometry.AsOrbGeometry()
+		if g == nil {
+			continue
+		}
+
+		f := geojson.NewFeature(g)
+		f.Properties["oid"] = oid
+		f.Properties["name"] = name
+
+		fc, ok := byType[zoneType]
+		if !ok {
+			fc = geojson.NewFeatureCollection()
+			byType[zoneType] = fc
+		}
+		fc.Append(f)
+	}
+
+	return byType, rows.Err()
+}
+
+// resolution returns the coarsest stored resolution that is finer than the simplification
+// tolerance at zoom z, so less detail is read from the DB only to be simplified away
+func (e *Encoder) resolution(z maptile.Zoom) geodata.Resolution {
+	if e.o.tolerance == 0 {
+		return geodata.ResolutionFull
+	}
+
+	unit := 360 / (float64(uint64(1)<<z) * float64(e.o.extent))
+	return geodata.ResolutionForTolerance(unit * e.o.tolerance)
+}
+
+// newTile validates z/x/y
+func newTile(z, x, y uint32) (maptile.Tile, error) {
+	if z > maxZoom || x >= 1<<z || y >= 1<<z {
+		return maptile.Tile{}, fmt.Errorf("%w: %d/%d/%d", ErrInvalidTile, z, x, y)
+	}
+
+	return maptile.New(x, y, maptile.Zoom(z)), nil
+}
+
+// bufferedBound returns the bound of the tile, in degrees, grown by buffer tile units on each side
+func bufferedBound(tile maptile.Tile, extent uint32, buffer float64) orb.Bound {
+	bound := tile.Bound()
+	if extent == 0 || buffer == 0 {
+		return bound
+	}
+
+	dx := (bound.Max.Lon() - bound.Min.Lon()) * buffer / float64(extent)
+	dy := (bound.Max.Lat() - bound.Min.Lat()) * buffer / float64(extent)
+
+	return orb.Bound{
+		Min: orb.Point{bound.Min.Lon() - dx, max(bound.Min.Lat()-dy, -90)},
+		Max: orb.Point{bound.Max.Lon() + dx, min(bound.Max.Lat()+dy, 90)},
+	}
+}
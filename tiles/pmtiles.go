@@ -0,0 +1,279 @@
+package tiles
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/paulmach/orb"
+)
+
+// Values of the PMTiles v3 header, from https://github.com/protomaps/PMTiles/blob/main/spec/v3/spec.md
+const (
+	pmtilesHeaderLength = 127
+	// the header and root directory must fit in the first 16 KiB, so clients can fetch both at once
+	pmtilesRootLimit = 16384 - pmtilesHeaderLength
+
+	pmtilesCompressionGzip = 2
+	pmtilesTileTypeMVT     = 1
+
+	pmtilesMinLeafSize = 4096
+)
+
+// pmtilesEntry is an entry of a PMTiles directory. In the root directory, an entry with a
+// RunLength of 0 points to a leaf directory instead of tile data
+type pmtilesEntry struct {
+	TileID    uint64
+	Offset    uint64
+	Length    uint32
+	RunLength uint32
+}
+
+// pmtilesWriter builds a clustered PMTiles v3 archive of gzipped vector tiles. Tiles must be added
+// in increasing tile ID order. Tile data is spooled to a temporary file until the archive is
+// written, and identical tiles, such as open water, are stored once
+type pmtilesWriter struct {
+	data     *os.File
+	offset   uint64
+	entries  []pmtilesEntry
+	contents map[[sha256.Size]byte]pmtilesEntry
+	tiles    uint64
+	minZoom  uint8
+	maxZoom  uint8
+	hasTiles bool
+}
+
+func newPMTilesWriter() (*pmtilesWriter, error) {
+	data, err := os.CreateTemp("", "pmtiles-*.data")
+	if err != nil {
+		return nil, err
+	}
+
+	return &pmtilesWriter{data: data, contents: map[[sha256.Size]byte]pmtilesEntry{}}, nil
+}
+
+// Add adds the gzipped tile at z/x/y, which must come after every tile already added
+func (pw *pmtilesWriter) Add(z uint8, x, y uint32, tile []byte) error {
+	id := tileID(z, x, y)
+	pw.tiles++
+
+	if !pw.hasTiles || z < pw.minZoom {
+		pw.minZoom = z
+	}
+
+	if !pw.hasTiles || z > pw.maxZoom {
+		pw.maxZoom = z
+	}
+	pw.hasTiles = true
+
+	sum := sha256.Sum256(tile)
+	if found, ok := pw.contents[sum]; ok {
+		// a run of identical tiles is one entry
+		if last := len(pw.entries) - 1; last >= 0 && pw.entries[last].Offset == found.Offset &&
+			pw.entries[last].TileID+uint64(pw.entries[last].RunLength) == id {
+			pw.entries[last].RunLength++
+			return nil
+		}
+
+		pw.entries = append(pw.entries, pmtilesEntry{TileID: id, Offset: found.Offset, Length: found.Length, RunLength: 1})
+		return nil
+	}
+
+	if _, err := pw.data.Write(tile); err != nil {
+		return err
+	}
+
+	e := pmtilesEntry{TileID: id, Offset: pw.offset, Length: uint32(len(tile)), RunLength: 1}
+	pw.contents[sum] = e
+	pw.entries = append(pw.entries, e)
+	pw.offset += uint64(len(tile))
+
+	return nil
+}
+
+// WriteTo writes the archive to w, with the bounds of its tiles and its JSON metadata
+func (pw *pmtilesWriter) WriteTo(w io.Writer, bounds orb.Bound, metadata any) error {
+	root, leaves, err := buildDirectories(pw.entries)
+	if err != nil {
+		return err
+	}
+
+	meta, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	if meta, err = gzipBytes(meta); err != nil {
+		return err
+	}
+
+	center := bounds.Center()
+	h := make([]byte, 0, pmtilesHeaderLength)
+	h = append(h, "PMTiles"...)
+	h = append(h, 3)
+
+	rootOffset := uint64(pmtilesHeaderLength)
+	metaOffset := rootOffset + uint64(len(root))
+	leafOffset := metaOffset + uint64(len(meta))
+	dataOffset := leafOffset + uint64(len(leaves))
+	for _, v := range []uint64{
+		rootOffset, uint64(len(root)),
+		metaOffset, uint64(len(meta)),
+		leafOffset, uint64(len(leaves)),
+		dataOffset, pw.offset,
+		pw.tiles, uint64(len(pw.entries)), uint64(len(pw.contents)),
+	} {
+		h = binary.LittleEndian.AppendUint64(h, v)
+	}
+
+	// clustered, internal compression, tile compression, tile type, min zoom, max zoom
+	h = append(h, 1, pmtilesCompressionGzip, pmtilesCompressionGzip, pmtilesTileTypeMVT, pw.minZoom, pw.maxZoom)
+	for _, v := range []float64{bounds.Min.Lon(), bounds.Min.Lat(), bounds.Max.Lon(), bounds.Max.Lat()} {
+		h = binary.LittleEndian.AppendUint32(h, uint32(e7(v)))
+	}
+
+	h = append(h, pw.minZoom)
+	h = binary.LittleEndian.AppendUint32(h, uint32(e7(center.Lon())))
+	h = binary.LittleEndian.AppendUint32(h, uint32(e7(center.Lat())))
+
+	for _, b := range [][]byte{h, root, meta, leaves} {
+		if _, err = w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	if _, err = pw.data.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, pw.data)
+	return err
+}
+
+// Close removes the temporary tile data
+func (pw *pmtilesWriter) Close() error {
+	err := pw.data.Close()
+	if rmErr := os.Remove(pw.data.Name()); err == nil {
+		err = rmErr
+	}
+
+	return err
+}
+
+// buildDirectories serializes entries as a root directory, splitting them into leaf directories
+// when the root would not fit in the first 16 KiB of the archive
+func buildDirectories(entries []pmtilesEntry) (root, leaves []byte, err error) {
+	if root, err = serializeDirectory(entries); err != nil || len(root) <= pmtilesRootLimit {
+		return root, nil, err
+	}
+
+	leafSize := max(pmtilesMinLeafSize, len(entries)/3500)
+	for {
+		var rootEntries []pmtilesEntry
+		leaves = leaves[:0]
+
+		for start := 0; start < len(entries); start += leafSize {
+			leaf, err := serializeDirectory(entries[start:min(start+leafSize, len(entries))])
+			if err != nil {
+				return nil, nil, err
+			}
+
+			rootEntries = append(rootEntries, pmtilesEntry{
+				TileID: entries[start].TileID,
+				Offset: uint64(len(leaves)),
+				Length: uint32(len(leaf)),
+			})
+			leaves = append(leaves, leaf...)
+		}
+
+		if root, err = serializeDirectory(rootEntries); err != nil || len(root) <= pmtilesRootLimit {
+			return root, leaves, err
+		}
+
+		leafSize += leafSize / 5
+	}
+}
+
+// serializeDirectory encodes entries, which are sorted by tile ID, as a gzipped PMTiles directory
+func serializeDirectory(entries []pmtilesEntry) ([]byte, error) {
+	buf := binary.AppendUvarint(nil, uint64(len(entries)))
+
+	var last uint64
+	for _, e := range entries {
+		buf = binary.AppendUvarint(buf, e.TileID-last)
+		last = e.TileID
+	}
+
+	for _, e := range entries {
+		buf = binary.AppendUvarint(buf, uint64(e.RunLength))
+	}
+
+	for _, e := range entries {
+		buf = binary.AppendUvarint(buf, uint64(e.Length))
+	}
+
+	for i, e := range entries {
+		// an offset of zero means the entry follows the previous one
+		if i > 0 && e.Offset == entries[i-1].Offset+uint64(entries[i-1].Length) {
+			buf = binary.AppendUvarint(buf, 0)
+		} else {
+			buf = binary.AppendUvarint(buf, e.Offset+1)
+		}
+	}
+
+	return gzipBytes(buf)
+}
+
+func gzipBytes(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// tileID returns the PMTiles ID of z/x/y: the number of tiles at lower zooms, plus the position
+// of the tile along a Hilbert curve through its zoom
+func tileID(z uint8, x, y uint32) uint64 {
+	id := ((uint64(1) << (2 * uint64(z))) - 1) / 3
+	if z == 0 {
+		return id
+	}
+
+	for s := uint32(1) << (z - 1); s > 0; s >>= 1 {
+		rx, ry := s&x, s&y
+		id += uint64((3*rx)^ry) * uint64(s)
+
+		if ry == 0 {
+			if rx != 0 {
+				x, y = s-1-x, s-1-y
+			}
+			x, y = y, x
+		}
+	}
+
+	return id
+}
+
+// sortByTileID sorts tiles at one zoom by their PMTiles ID
+func sortByTileID(z uint8, tiles [][2]uint32) {
+	sort.Slice(tiles, func(i, j int) bool {
+		return tileID(z, tiles[i][0], tiles[i][1]) < tileID(z, tiles[j][0], tiles[j][1])
+	})
+}
+
+func e7(deg float64) int32 {
+	return int32(math.Round(deg * 1e7))
+}
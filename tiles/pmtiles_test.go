@@ -0,0 +1,281 @@
+package tiles
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/watchedsky-social/libwatchedsky"
+)
+
+func TestTileID(t *testing.T) {
+	tests := []struct {
+		z    uint8
+		x, y uint32
+		want uint64
+	}{
+		{0, 0, 0, 0},
+		{1, 0, 0, 1},
+		{1, 0, 1, 2},
+		{1, 1, 1, 3},
+		{1, 1, 0, 4},
+		{2, 0, 0, 5},
+		// from the go-pmtiles and pmtiles JS test suites
+		{12, 3423, 1763, 19078479},
+	}
+
+	for _, tt := range tests {
+		if got := tileID(tt.z, tt.x, tt.y); got != tt.want {
+			t.Errorf("tileID(%d, %d, %d) = %d, want %d", tt.z, tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestTileIDsAreUniquePerZoom(t *testing.T) {
+	const z = 4
+
+	seen := map[uint64]bool{}
+	first, last := tileID(z, 0, 0), tileID(z, 0, 0)
+	for x := uint32(0); x < 1<<z; x++ {
+		for y := uint32(0); y < 1<<z; y++ {
+			id := tileID(z, x, y)
+			if seen[id] {
+				t.Fatalf("tileID(%d, %d, %d) = %d is not unique", z, x, y, id)
+			}
+			seen[id] = true
+			first, last = min(first, id), max(last, id)
+		}
+	}
+
+	// the IDs of a zoom follow on from the previous zoom without gaps
+	if first != tileID(z-1, 0, 0)+1<<(2*(z-1)) || last != first+1<<(2*z)-1 {
+		t.Errorf("IDs at zoom %d run from %d to %d", z, first, last)
+	}
+}
+
+// readDirectory decodes a gzipped PMTiles directory
+func readDirectory(t *testing.T, data []byte) []pmtilesEntry {
+	t.Helper()
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := bytes.NewReader(raw)
+	next := func() uint64 {
+		v, err := binary.ReadUvarint(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	entries := make([]pmtilesEntry, next())
+	var id uint64
+	for i := range entries {
+		id += next()
+		entries[i].TileID = id
+	}
+
+	for i := range entries {
+		entries[i].RunLength = uint32(next())
+	}
+
+	for i := range entries {
+		entries[i].Length = uint32(next())
+	}
+
+	for i := range entries {
+		if offset := next(); offset == 0 && i > 0 {
+			entries[i].Offset = entries[i-1].Offset + uint64(entries[i-1].Length)
+		} else {
+			entries[i].Offset = offset - 1
+		}
+	}
+
+	if r.Len() != 0 {
+		t.Errorf("directory has %d trailing bytes", r.Len())
+	}
+
+	return entries
+}
+
+func TestSerializeDirectory(t *testing.T) {
+	entries := []pmtilesEntry{
+		{TileID: 0, Offset: 0, Length: 10, RunLength: 1},
+		// contiguous with the entry before, so its offset is written as zero
+		{TileID: 1, Offset: 10, Length: 20, RunLength: 1},
+		// a run of tiles that reuse the first tile
+		{TileID: 2, Offset: 0, Length: 10, RunLength: 3},
+		{TileID: 5, Offset: 30, Length: 5, RunLength: 1},
+	}
+
+	data, err := serializeDirectory(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readDirectory(t, data); !slices.Equal(got, entries) {
+		t.Errorf("round trip = %+v, want %+v", got, entries)
+	}
+}
+
+func TestBuildDirectoriesWithLeaves(t *testing.T) {
+	// enough entries, with random gaps and lengths so they do not compress away, that the root
+	// cannot hold them all
+	rng := rand.New(rand.NewPCG(1, 2))
+	var entries []pmtilesEntry
+	var id, offset uint64
+	for range 20000 {
+		id += 1 + rng.Uint64N(1000)
+		length := 1 + rng.Uint32N(1<<16)
+		entries = append(entries, pmtilesEntry{TileID: id, Offset: offset, Length: length, RunLength: 1})
+		offset += uint64(length)
+	}
+
+	root, leaves, err := buildDirectories(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(root) > pmtilesRootLimit || len(leaves) == 0 {
+		t.Fatalf("root is %d bytes with %d bytes of leaves, want leaves and a root within %d bytes",
+			len(root), len(leaves), pmtilesRootLimit)
+	}
+
+	var got []pmtilesEntry
+	for _, e := range readDirectory(t, root) {
+		if e.RunLength != 0 {
+			t.Fatalf("root entry %+v does not point to a leaf", e)
+		}
+
+		leaf := readDirectory(t, leaves[e.Offset:e.Offset+uint64(e.Length)])
+		if leaf[0].TileID != e.TileID {
+			t.Errorf("root entry %d points to a leaf starting at %d", e.TileID, leaf[0].TileID)
+		}
+		got = append(got, leaf...)
+	}
+
+	if !slices.Equal(got, entries) {
+		t.Error("leaves do not hold every entry in order")
+	}
+}
+
+func TestPMTilesWriter(t *testing.T) {
+	pw, err := newPMTilesWriter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pw.Close()
+
+	tiles := []struct {
+		z    uint8
+		x, y uint32
+		data string
+	}{
+		{1, 0, 0, "a"},
+		{1, 0, 1, "b"},
+		{1, 1, 1, "b"},
+		{1, 1, 0, "c"},
+		{2, 0, 0, "a"},
+	}
+	for _, tile := range tiles {
+		if err = pw.Add(tile.z, tile.x, tile.y, []byte(tile.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bounds := orb.Bound{Min: orb.Point{-84.8, 38.4}, Max: orb.Point{-80.5, 42}}
+	var buf bytes.Buffer
+	if err = pw.WriteTo(&buf, bounds, map[string]string{"name": "zones"}); err != nil {
+		t.Fatal(err)
+	}
+	archive := buf.Bytes()
+
+	if string(archive[:7]) != "PMTiles" || archive[7] != 3 {
+		t.Fatalf("archive starts with %q, want a PMTiles v3 header", archive[:8])
+	}
+
+	u64 := func(i int) uint64 { return binary.LittleEndian.Uint64(archive[8+8*i:]) }
+	rootOffset, rootLength, metaOffset, metaLength := u64(0), u64(1), u64(2), u64(3)
+	leafLength, dataOffset, dataLength := u64(5), u64(6), u64(7)
+	addressed, entries, contents := u64(8), u64(9), u64(10)
+
+	if rootOffset != pmtilesHeaderLength || leafLength != 0 {
+		t.Errorf("root at %d with %d bytes of leaves, want it after the header and no leaves", rootOffset, leafLength)
+	}
+
+	// b is stored once, as a run of two tiles, and a is reused at zoom 2
+	if addressed != 5 || entries != 4 || contents != 3 || dataLength != 3 {
+		t.Errorf("addressed, entries, contents, data = %d, %d, %d, %d, want 5, 4, 3, 3",
+			addressed, entries, contents, dataLength)
+	}
+
+	if dataOffset+dataLength != uint64(len(archive)) || string(archive[dataOffset:]) != "abc" {
+		t.Errorf("tile data = %q, want %q", archive[dataOffset:], "abc")
+	}
+
+	flags := archive[96:102]
+	if !bytes.Equal(flags, []byte{1, pmtilesCompressionGzip, pmtilesCompressionGzip, pmtilesTileTypeMVT, 1, 2}) {
+		t.Errorf("clustered, compression, tile type and zooms = %v", flags)
+	}
+
+	if minLon := int32(binary.LittleEndian.Uint32(archive[102:])); minLon != -848000000 {
+		t.Errorf("min lon = %d, want -848000000", minLon)
+	}
+
+	want := []pmtilesEntry{
+		{TileID: 1, Offset: 0, Length: 1, RunLength: 1},
+		{TileID: 2, Offset: 1, Length: 1, RunLength: 2},
+		{TileID: 4, Offset: 2, Length: 1, RunLength: 1},
+		{TileID: 5, Offset: 0, Length: 1, RunLength: 1},
+	}
+	if got := readDirectory(t, archive[rootOffset:rootOffset+rootLength]); !slices.Equal(got, want) {
+		t.Errorf("root = %+v, want %+v", got, want)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(archive[metaOffset : metaOffset+metaLength]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var meta map[string]string
+	if err = json.NewDecoder(zr).Decode(&meta); err != nil || meta["name"] != "zones" {
+		t.Errorf("metadata = %v, %v, want the name", meta, err)
+	}
+}
+
+func TestExportPMTilesFileKeepsTargetOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "zones.pmtiles")
+	if err := os.WriteFile(file, []byte("previous"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ExportPMTilesFile(nil, nil, file); !errors.Is(err, libwatchedsky.ErrNilContext) {
+		t.Fatalf("ExportPMTilesFile(nil) = %v, want %v", err, libwatchedsky.ErrNilContext)
+	}
+
+	if data, err := os.ReadFile(file); err != nil || string(data) != "previous" {
+		t.Errorf("target = %q, %v, want it unchanged", data, err)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("directory has %d files, want the temporary archive removed", len(entries))
+	}
+}
@@ -0,0 +1,103 @@
+package topology
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+)
+
+// topoJSON is a TopoJSON Topology, as described at https://github.com/topojson/topojson-specification
+type topoJSON struct {
+	Type      string                `json:"type"`
+	BBox      [4]float64            `json:"bbox"`
+	Transform topoTransform         `json:"transform"`
+	Objects   map[string]collection `json:"objects"`
+	Arcs      [][][2]int64          `json:"arcs"`
+}
+
+type topoTransform struct {
+	Scale     [2]float64 `json:"scale"`
+	Translate [2]float64 `json:"translate"`
+}
+
+type collection struct {
+	Type       string         `json:"type"`
+	Geometries []topoGeometry `json:"geometries"`
+}
+
+type topoGeometry struct {
+	Type       *string        `json:"type"`
+	ID         string         `json:"id,omitempty"`
+	Properties map[string]any `json:"properties,omitempty"`
+	Arcs       any            `json:"arcs,omitempty"`
+}
+
+// WriteTopoJSON writes the topology to w as TopoJSON, with every object in one
+// GeometryCollection called name and arcs simplified at tolerance degrees. Arcs are quantized to
+// the grid of the topology and delta-encoded, which is far smaller than the GeoJSON of the same
+// objects since every shared boundary is written once
+func (t *Topology) WriteTopoJSON(w io.Writer, name string, tolerance float64) error {
+	arcs := t.render(tolerance)
+
+	minX, minY := int64(math.MaxInt64), int64(math.MaxInt64)
+	maxX, maxY := int64(math.MinInt64), int64(math.MinInt64)
+	for _, arc := range arcs {
+		for _, p := range arc {
+			minX, minY = min(minX, p[0]), min(minY, p[1])
+			maxX, maxY = max(maxX, p[0]), max(maxY, p[1])
+		}
+	}
+
+	if len(arcs) == 0 {
+		minX, minY, maxX, maxY = 0, 0, 0, 0
+	}
+
+	doc := topoJSON{
+		Type: "Topology",
+		BBox: [4]float64{
+			float64(minX) * t.quantum, float64(minY) * t.quantum,
+			float64(maxX) * t.quantum, float64(maxY) * t.quantum,
+		},
+		Transform: topoTransform{
+			Scale:     [2]float64{t.quantum, t.quantum},
+			Translate: [2]float64{float64(minX) * t.quantum, float64(minY) * t.quantum},
+		},
+		Objects: map[string]collection{},
+		Arcs:    make([][][2]int64, len(arcs)),
+	}
+
+	for i, arc := range arcs {
+		encoded := make([][2]int64, len(arc))
+		prev := point{minX, minY}
+		for j, p := range arc {
+			encoded[j] = [2]int64{p[0] - prev[0], p[1] - prev[1]}
+			prev = p
+		}
+
+		doc.Arcs[i] = encoded
+	}
+
+	geometries := make([]topoGeometry, 0, len(t.objects))
+	for _, obj := range t.objects {
+		g := topoGeometry{ID: obj.id, Properties: obj.properties}
+
+		switch {
+		case len(obj.polygons) == 0:
+			// a null geometry keeps the object and its properties
+		case !obj.multi && len(obj.polygons) == 1:
+			g.Type, g.Arcs = ptr("Polygon"), obj.polygons[0]
+		default:
+			g.Type, g.Arcs = ptr("MultiPolygon"), obj.polygons
+		}
+
+		geometries = append(geometries, g)
+	}
+
+	doc.Objects[name] = collection{Type: "GeometryCollection", Geometries: geometries}
+
+	return json.NewEncoder(w).Encode(doc)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,398 @@
+// Package topology builds a TopoJSON-style topology from zone polygons: the boundary shared by two
+// neighboring zones is stored once, as an arc that both zones reference. Simplifying arcs instead
+// of whole polygons moves a shared boundary the same way for both neighbors, so simplified zones
+// keep meeting without gaps or slivers
+package topology
+
+import (
+	"math"
+	"slices"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/simplify"
+)
+
+// DefaultQuantum is the grid, in degrees, that coordinates are snapped to before boundaries are
+// matched, about a centimeter. Neighboring zones whose vertices differ by less than this share
+// their boundary
+const DefaultQuantum = 1e-7
+
+// Object is a polygonal feature to add to a topology
+type Object struct {
+	ID         string
+	Properties map[string]any
+	// Geometry is an orb.Polygon or orb.MultiPolygon. Other geometries are ignored
+	Geometry orb.Geometry
+}
+
+// point is a coordinate snapped to the quantization grid
+type point [2]int64
+
+// polygon is a list of rings, each a list of arc references. A reference i >= 0 is arc i, and
+// ^i is arc i reversed, as in TopoJSON
+type polygon [][]int
+
+type object struct {
+	id         string
+	properties map[string]any
+	polygons   []polygon
+	multi      bool
+}
+
+// Topology is a set of polygonal objects whose boundaries are split into shared arcs
+type Topology struct {
+	quantum float64
+	arcs    [][]point
+	objects []object
+}
+
+// Option configures [New]
+type Option func(o *options)
+
+type options struct {
+	quantum float64
+}
+
+// WithQuantum sets the grid, in degrees, that coordinates are snapped to. The default is
+// [DefaultQuantum]
+func WithQuantum(quantum float64) Option {
+	return func(o *options) {
+		if quantum > 0 {
+			o.quantum = quantum
+		}
+	}
+}
+
+// New builds the topology of objects. Rings are cut into arcs at every junction, a point where
+// the neighbors on either side of a boundary change, and arcs that appear in more than one ring,
+// in either direction, are stored once
+func New(objects []Object, opts ...Option) *Topology {
+	o := options{quantum: DefaultQuantum}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	t := &Topology{quantum: o.quantum}
+
+	// snap every ring to the grid first, since junctions depend on all of them
+	type ringSet struct {
+		obj   Object
+		multi bool
+		polys [][][]point
+	}
+
+	sets := make([]ringSet, 0, len(objects))
+	var all [][]point
+	for _, obj := range objects {
+		var polys []orb.Polygon
+		multi := false
+		switch g := obj.Geometry.(type) {
+		case orb.Polygon:
+			polys = []orb.Polygon{g}
+		case orb.MultiPolygon:
+			polys, multi = g, true
+		default:
+			continue
+		}
+
+		rs := ringSet{obj: obj, multi: multi}
+		for _, p := range polys {
+			// a polygon whose outer ring collapses on the grid is too small to keep
+			if len(p) == 0 || t.quantizeRing(p[0]) == nil {
+				continue
+			}
+
+			var rings [][]point
+			for _, r := range p {
+				if q := t.quantizeRing(r); q != nil {
+					rings = append(rings, q)
+					all = append(all, q)
+				}
+			}
+
+			rs.polys = append(rs.polys, rings)
+		}
+
+		sets = append(sets, rs)
+	}
+
+	junctions := findJunctions(all)
+
+	index := map[string]int{}
+	for _, rs := range sets {
+		obj := object{id: rs.obj.ID, properties: rs.obj.Properties, multi: rs.multi}
+		for _, p := range rs.polys {
+			var poly polygon
+			for _, r := range p {
+				poly = append(poly, t.cutRing(r, junctions, index))
+			}
+
+			obj.polygons = append(obj.polygons, poly)
+		}
+
+		t.objects = append(t.objects, obj)
+	}
+
+	return t
+}
+
+// Arcs returns the number of distinct arcs in the topology
+func (t *Topology) Arcs() int {
+	return len(t.arcs)
+}
+
+// Objects reassembles every object from arcs simplified with the Douglas-Peucker algorithm at
+// tolerance degrees. Arc endpoints are junctions and are never moved, so neighbors still share
+// their simplified boundary. A ring that simplification would collapse keeps its full detail, as
+// do the boundaries its neighbors share with it. A tolerance of 0 returns the objects as snapped
+// to the grid
+func (t *Topology) Objects(tolerance float64) []Object {
+	arcs := t.render(tolerance)
+
+	out := make([]Object, 0, len(t.objects))
+	for _, obj := range t.objects {
+		mp := make(orb.MultiPolygon, 0, len(obj.polygons))
+		for _, p := range obj.polygons {
+			poly := make(orb.Polygon, 0, len(p))
+			for _, refs := range p {
+				poly = append(poly, t.ring(arcs, refs))
+			}
+
+			mp = append(mp, poly)
+		}
+
+		var g orb.Geometry = mp
+		if !obj.multi && len(mp) == 1 {
+			g = mp[0]
+		}
+
+		out = append(out, Object{ID: obj.id, Properties: obj.properties, Geometry: g})
+	}
+
+	return out
+}
+
+// render simplifies every arc at tolerance. The arcs of a ring that simplification would collapse
+// keep their full detail, in every ring that shares them, so its neighbors still meet it
+func (t *Topology) render(tolerance float64) [][]point {
+	if tolerance <= 0 {
+		return t.arcs
+	}
+
+	arcs := make([][]point, len(t.arcs))
+	for i, arc := range t.arcs {
+		arcs[i] = t.simplifyArc(arc, tolerance)
+	}
+
+	// restoring arcs only ever lengthens rings, so one pass leaves none collapsed
+	for _, obj := range t.objects {
+		for _, p := range obj.polygons {
+			for _, refs := range p {
+				if ringLength(arcs, refs) >= 4 {
+					continue
+				}
+
+				for _, ref := range refs {
+					arcs[arcIndex(ref)] = t.arcs[arcIndex(ref)]
+				}
+			}
+		}
+	}
+
+	return arcs
+}
+
+// simplifyArc simplifies arc, keeping both endpoints. A closed arc, a ring with no junctions, is
+// kept whole if simplification would leave it without area
+func (t *Topology) simplifyArc(arc []point, tolerance float64) []point {
+	if len(arc) <= 2 {
+		return arc
+	}
+
+	ls := make(orb.LineString, len(arc))
+	for i, p := range arc {
+		ls[i] = t.unquantize(p)
+	}
+
+	simplified := simplify.DouglasPeucker(tolerance).LineString(ls)
+	if arc[0] == arc[len(arc)-1] && len(simplified) < 4 {
+		return arc
+	}
+
+	out := make([]point, len(simplified))
+	for i, p := range simplified {
+		out[i] = t.quantize(p)
+	}
+
+	return out
+}
+
+// ring joins the arcs referenced by refs into a closed ring
+func (t *Topology) ring(arcs [][]point, refs []int) orb.Ring {
+	var r orb.Ring
+	for _, ref := range refs {
+		arc := arcs[arcIndex(ref)]
+		for i := range arc {
+			p := arc[i]
+			if ref < 0 {
+				p = arc[len(arc)-1-i]
+			}
+
+			// each arc starts where the previous one ended
+			if i == 0 && len(r) > 0 {
+				continue
+			}
+
+			r = append(r, t.unquantize(p))
+		}
+	}
+
+	return r
+}
+
+// ringLength returns the number of points in the ring that refs would join into
+func ringLength(arcs [][]point, refs []int) int {
+	n := 1
+	for _, ref := range refs {
+		n += len(arcs[arcIndex(ref)]) - 1
+	}
+
+	return n
+}
+
+func arcIndex(ref int) int {
+	if ref < 0 {
+		return ^ref
+	}
+
+	return ref
+}
+
+// quantizeRing snaps r to the grid, dropping repeated points and the closing point. It returns
+// nil if fewer than three distinct points are left
+func (t *Topology) quantizeRing(r orb.Ring) []point {
+	out := make([]point, 0, len(r))
+	for _, p := range r {
+		q := t.quantize(p)
+		if len(out) == 0 || out[len(out)-1] != q {
+			out = append(out, q)
+		}
+	}
+
+	for len(out) > 1 && out[0] == out[len(out)-1] {
+		out = out[:len(out)-1]
+	}
+
+	if len(out) < 3 {
+		return nil
+	}
+
+	return out
+}
+
+func (t *Topology) quantize(p orb.Point) point {
+	return point{int64(math.Round(p[0] / t.quantum)), int64(math.Round(p[1] / t.quantum))}
+}
+
+func (t *Topology) unquantize(p point) orb.Point {
+	return orb.Point{float64(p[0]) * t.quantum, float64(p[1]) * t.quantum}
+}
+
+// findJunctions returns the points where boundaries meet. A point is a junction if it is seen
+// with different neighbors in different rings, since that is where one shared boundary ends and
+// the next begins
+func findJunctions(rings [][]point) map[point]bool {
+	type neighbors struct {
+		prev, next point
+	}
+
+	seen := map[point]neighbors{}
+	junctions := map[point]bool{}
+
+	for _, r := range rings {
+		for i, p := range r {
+			n := neighbors{prev: r[(i+len(r)-1)%len(r)], next: r[(i+1)%len(r)]}
+
+			s, ok := seen[p]
+			if !ok {
+				seen[p] = n
+				continue
+			}
+
+			if s != n && (s.prev != n.next || s.next != n.prev) {
+				junctions[p] = true
+			}
+		}
+	}
+
+	return junctions
+}
+
+// cutRing splits r into arcs at its junctions, adding new arcs to the topology, and returns the
+// references that make up the ring
+func (t *Topology) cutRing(r []point, junctions map[point]bool, index map[string]int) []int {
+	start := slices.IndexFunc(r, func(p point) bool { return junctions[p] })
+	if start < 0 {
+		// a ring with no junctions is one closed arc. It starts at its smallest point, so the same
+		// ring in another object is recognized
+		start = 0
+		for i, p := range r {
+			if p[0] < r[start][0] || (p[0] == r[start][0] && p[1] < r[start][1]) {
+				start = i
+			}
+		}
+
+		rotated := append(slices.Clone(r[start:]), r[:start]...)
+		return []int{t.addArc(append(rotated, rotated[0]), index)}
+	}
+
+	rotated := append(slices.Clone(r[start:]), r[:start]...)
+	rotated = append(rotated, rotated[0])
+
+	var refs []int
+	from := 0
+	for i := 1; i < len(rotated); i++ {
+		if i == len(rotated)-1 || junctions[rotated[i]] {
+			refs = append(refs, t.addArc(rotated[from:i+1], index))
+			from = i
+		}
+	}
+
+	return refs
+}
+
+// addArc returns a reference to arc, adding it to the topology unless it, or its reverse, is
+// already there
+func (t *Topology) addArc(arc []point, index map[string]int) int {
+	key := arcKey(arc)
+	if i, ok := index[key]; ok {
+		return i
+	}
+
+	reversed := slices.Clone(arc)
+	slices.Reverse(reversed)
+
+	// a closed arc reversed starts at the same point, so the same ring wound the other way matches
+	if i, ok := index[arcKey(reversed)]; ok {
+		return ^i
+	}
+
+	i := len(t.arcs)
+	t.arcs = append(t.arcs, slices.Clone(arc))
+	index[key] = i
+
+	return i
+}
+
+func arcKey(arc []point) string {
+	b := make([]byte, 0, len(arc)*16)
+	for _, p := range arc {
+		for _, v := range p {
+			u := uint64(v)
+			b = append(b, byte(u), byte(u>>8), byte(u>>16), byte(u>>24), byte(u>>32), byte(u>>40),
+				byte(u>>48), byte(u>>56))
+		}
+	}
+
+	return string(b)
+}
@@ -0,0 +1,221 @@
+package topology
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/paulmach/orb"
+)
+
+func square(minX, minY, maxX, maxY float64) orb.Ring {
+	return orb.Ring{{minX, minY}, {maxX, minY}, {maxX, maxY}, {minX, maxY}, {minX, minY}}
+}
+
+func TestNew(t *testing.T) {
+	hole := orb.Ring{{1, 1}, {1, 3}, {3, 3}, {3, 1}, {1, 1}}
+
+	tests := []struct {
+		name     string
+		objects  []Object
+		arcs     int
+		polygons [][]polygon
+	}{
+		{
+			name: "squares sharing an edge",
+			objects: []Object{
+				{ID: "a", Geometry: orb.Polygon{square(0, 0, 1, 1)}},
+				{ID: "b", Geometry: orb.Polygon{square(1, 0, 2, 1)}},
+			},
+			arcs: 3,
+			// the shared edge is arc 0, which b runs the other way
+			polygons: [][]polygon{{{{0, 1}}}, {{{2, ^0}}}},
+		},
+		{
+			name: "enclave in a hole",
+			objects: []Object{
+				{ID: "outer", Geometry: orb.Polygon{square(0, 0, 4, 4), hole}},
+				{ID: "enclave", Geometry: orb.MultiPolygon{{square(1, 1, 3, 3)}}},
+			},
+			arcs: 2,
+			// the hole has no junctions, so it is one closed arc that the enclave matches reversed
+			polygons: [][]polygon{{{{0}, {1}}}, {{{^1}}}},
+		},
+		{
+			name: "collapsed and non-polygonal objects",
+			objects: []Object{
+				{ID: "point", Geometry: orb.Point{0, 0}},
+				{ID: "tiny", Geometry: orb.Polygon{square(0, 0, 0.1, 0.1)}},
+			},
+			arcs:     0,
+			polygons: [][]polygon{nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topo := New(tt.objects, WithQuantum(1))
+			if topo.Arcs() != tt.arcs {
+				t.Errorf("Arcs() = %d, want %d", topo.Arcs(), tt.arcs)
+			}
+
+			var polygons [][]polygon
+			for _, obj := range topo.objects {
+				polygons = append(polygons, obj.polygons)
+			}
+
+			if !reflect.DeepEqual(polygons, tt.polygons) {
+				t.Errorf("polygons = %v, want %v", polygons, tt.polygons)
+			}
+		})
+	}
+}
+
+func TestObjectsRoundTrip(t *testing.T) {
+	objects := []Object{
+		{ID: "a", Properties: map[string]any{"name": "A"}, Geometry: orb.Polygon{square(0, 0, 1, 1)}},
+		{ID: "b", Geometry: orb.MultiPolygon{{square(1, 0, 2, 1)}}},
+	}
+
+	got := New(objects, WithQuantum(1)).Objects(0)
+	if len(got) != len(objects) {
+		t.Fatalf("Objects() returned %d objects, want %d", len(got), len(objects))
+	}
+
+	for i, obj := range got {
+		if obj.ID != objects[i].ID || !reflect.DeepEqual(obj.Properties, objects[i].Properties) {
+			t.Errorf("object %d = %s %v, want %s %v", i, obj.ID, obj.Properties, objects[i].ID, objects[i].Properties)
+		}
+
+		if reflect.TypeOf(obj.Geometry) != reflect.TypeOf(objects[i].Geometry) {
+			t.Errorf("%s is a %T, want a %T", obj.ID, obj.Geometry, objects[i].Geometry)
+		}
+
+		// rings start at a junction, so compare the points they pass through
+		want := objects[i].Geometry.Bound()
+		if b := obj.Geometry.Bound(); b != want {
+			t.Errorf("%s bound = %v, want %v", obj.ID, b, want)
+		}
+	}
+}
+
+func TestObjectsKeepCollapsedRingsShared(t *testing.T) {
+	// a sliver on top of a square, whose shared boundary dips slightly below the line between the
+	// two junctions
+	sliver := orb.Ring{{0, 0}, {50, -1}, {100, 0}, {50, 10}, {0, 0}}
+	below := orb.Ring{{0, 0}, {0, -100}, {100, -100}, {100, 0}, {50, -1}, {0, 0}}
+
+	topo := New([]Object{
+		{ID: "sliver", Geometry: orb.Polygon{sliver}},
+		{ID: "below", Geometry: orb.Polygon{below}},
+	}, WithQuantum(1))
+
+	// simplified, both arcs of the sliver are straight lines between the junctions
+	got := topo.Objects(20)
+
+	s := got[0].Geometry.(orb.Polygon)[0]
+	if len(s) < 4 {
+		t.Fatalf("sliver = %v, want it kept at full detail", s)
+	}
+
+	b := got[1].Geometry.(orb.Polygon)[0]
+	if !slices.Contains(s, orb.Point{50, -1}) || !slices.Contains(b, orb.Point{50, -1}) {
+		t.Errorf("sliver = %v, below = %v, want both to keep their shared boundary", s, b)
+	}
+}
+
+func TestFindJunctions(t *testing.T) {
+	a := []point{{0, 0}, {1, 0}, {1, 1}, {0, 1}}
+	b := []point{{1, 0}, {2, 0}, {2, 1}, {1, 1}}
+	reversed := []point{{0, 1}, {1, 1}, {1, 0}, {0, 0}}
+
+	tests := []struct {
+		name  string
+		rings [][]point
+		want  []point
+	}{
+		{name: "one ring", rings: [][]point{a}},
+		{name: "shared edge", rings: [][]point{a, b}, want: []point{{1, 0}, {1, 1}}},
+		{name: "same ring reversed", rings: [][]point{a, reversed}},
+		{name: "same ring repeated", rings: [][]point{a, a}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := slices.SortedFunc(maps.Keys(findJunctions(tt.rings)), func(p, q point) int {
+				if p[0] != q[0] {
+					return int(p[0] - q[0])
+				}
+				return int(p[1] - q[1])
+			})
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("findJunctions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCutRing(t *testing.T) {
+	junctions := map[point]bool{{1, 0}: true, {1, 1}: true}
+
+	tests := []struct {
+		name string
+		ring []point
+		refs []int
+		arcs [][]point
+	}{
+		{
+			name: "cut at junctions",
+			ring: []point{{0, 0}, {1, 0}, {1, 1}, {0, 1}},
+			refs: []int{0, 1},
+			arcs: [][]point{{{1, 0}, {1, 1}}, {{1, 1}, {0, 1}, {0, 0}, {1, 0}}},
+		},
+		{
+			name: "no junctions starts at the smallest point",
+			ring: []point{{3, 3}, {3, 2}, {2, 2}, {2, 3}},
+			refs: []int{0},
+			arcs: [][]point{{{2, 2}, {2, 3}, {3, 3}, {3, 2}, {2, 2}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topo := &Topology{quantum: 1}
+			if refs := topo.cutRing(tt.ring, junctions, map[string]int{}); !slices.Equal(refs, tt.refs) {
+				t.Errorf("refs = %v, want %v", refs, tt.refs)
+			}
+
+			if !reflect.DeepEqual(topo.arcs, tt.arcs) {
+				t.Errorf("arcs = %v, want %v", topo.arcs, tt.arcs)
+			}
+		})
+	}
+}
+
+func TestCutRingMatchesReversedArcs(t *testing.T) {
+	junctions := map[point]bool{{1, 0}: true, {1, 1}: true}
+	topo := &Topology{quantum: 1}
+	index := map[string]int{}
+
+	topo.cutRing([]point{{0, 0}, {1, 0}, {1, 1}, {0, 1}}, junctions, index)
+
+	// the neighbor runs the shared edge from (1, 1) to (1, 0)
+	if refs := topo.cutRing([]point{{1, 0}, {2, 0}, {2, 1}, {1, 1}}, junctions, index); !slices.Equal(refs, []int{2, ^0}) {
+		t.Errorf("neighbor refs = %v, want [2 %d]", refs, ^0)
+	}
+
+	// a closed ring wound the other way is the same arc reversed
+	closed := []point{{2, 2}, {2, 3}, {3, 3}, {3, 2}}
+	i := topo.cutRing(closed, junctions, index)[0]
+
+	slices.Reverse(closed)
+	if refs := topo.cutRing(closed, junctions, index); !slices.Equal(refs, []int{^i}) {
+		t.Errorf("reversed ring refs = %v, want [%d]", refs, ^i)
+	}
+
+	if topo.Arcs() != 4 {
+		t.Errorf("Arcs() = %d, want 4", topo.Arcs())
+	}
+}
@@ -0,0 +1,117 @@
+package topology
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/watchedsky-social/libwatchedsky"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+)
+
+const (
+	selectZoneTypes = `SELECT DISTINCT type FROM zones WHERE retired_at IS NULL AND geometry IS NOT NULL ORDER BY type`
+	selectZones     = `SELECT oid, name, type, ST_AsBinary(geometry) FROM zones
+WHERE retired_at IS NULL AND geometry IS NOT NULL AND type = ?
+ORDER BY oid`
+	updateSimplifiedGeometry = `UPDATE zones SET %s = GeosMakeValid(ST_GeomFromWKB(?, 4326)) WHERE oid = ?`
+)
+
+// FromZones builds the topology of the active zones of one type, such as "county". Zones of
+// different types overlap rather than tile, so each type is its own topology. Each object has the
+// OID of its zone as its ID, and its name and type as properties
+func FromZones(ctx context.Context, q geodata.Querier, zoneType string, opts ...Option) (*Topology, error) {
+	if ctx == nil {
+		return nil, libwatchedsky.ErrNilContext
+	}
+
+	rows, err := q.QueryContext(ctx, selectZones, zoneType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objects []Object
+	for rows.Next() {
+		var (
+			oid, name, t string
+			geometry     geodata.Geometry
+		)
+
+		if err = rows.Scan(&oid, &name, &t, &geometry); err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, Object{
+			ID:         oid,
+			Properties: map[string]any{"name": name, "type": t},
+			Geometry:   geometry.AsOrbGeometry(),
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return New(objects, opts...), nil
+}
+
+// SimplifyZoneGeometries replaces the simplified geometries of every active zone, which
+// [geodata.SimplifyZoneGeometries] computes one zone at a time, with ones simplified from the
+// topology of each zone type, so that neighboring zones still meet at every resolution
+func SimplifyZoneGeometries(ctx context.Context, q geodata.Querier, opts ...Option) error {
+	if ctx == nil {
+		return libwatchedsky.ErrNilContext
+	}
+
+	types, err := zoneTypes(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	resolutions := []geodata.Resolution{geodata.Resolution10m, geodata.Resolution100m, geodata.Resolution1km}
+	for _, zoneType := range types {
+		t, err := FromZones(ctx, q, zoneType, opts...)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range resolutions {
+			query := fmt.Sprintf(updateSimplifiedGeometry, r.Column())
+			for _, obj := range t.Objects(r.Tolerance()) {
+				if _, err = q.ExecContext(ctx, query, geodata.FromOrbGeometry(obj.Geometry), obj.ID); err != nil {
+					return fmt.Errorf("%s: %w", obj.ID, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// ZoneSimplifier returns a [geodata.ZoneSimplifier] that runs [SimplifyZoneGeometries] with opts,
+// for [geodata.ZoneUpdateOptions]
+func ZoneSimplifier(opts ...Option) geodata.ZoneSimplifier {
+	return func(ctx context.Context, q geodata.Querier) error {
+		return SimplifyZoneGeometries(ctx, q, opts...)
+	}
+}
+
+func zoneTypes(ctx context.Context, q geodata.Querier) ([]string, error) {
+	rows, err := q.QueryContext(ctx, selectZoneTypes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var types []string
+	for rows.Next() {
+		var t string
+		if err = rows.Scan(&t); err != nil {
+			return nil, err
+		}
+
+		types = append(types, t)
+	}
+
+	return types, rows.Err()
+}
@@ -0,0 +1,287 @@
+package vtec
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/watchedsky-social/libwatchedsky"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+)
+
+const (
+	selectEventExists = `SELECT EXISTS (SELECT 1 FROM vtec_events WHERE event_key = ?)`
+	// an event that no product has ended and that has not run past its end time
+	selectEventUnexpired = `SELECT EXISTS (SELECT 1 FROM vtec_events
+WHERE event_key = ?1 AND (ends IS NULL OR ends > ?2)
+  AND last_action NOT IN ('` + string(ActionCancel) + `', '` + string(ActionExpire) + `', '` + string(ActionUpgrade) + `'))`
+	upsertEvent = `INSERT INTO vtec_events
+  (event_key, office, phenomena, significance, etn, year, begins, ends, last_action, first_seen, updated_at)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?10)
+ON CONFLICT (event_key) DO UPDATE SET begins = COALESCE(excluded.begins, begins),
+                                      ends = COALESCE(excluded.ends, ends),
+                                      last_action = excluded.last_action,
+                                      updated_at = excluded.updated_at`
+	selectEventZones = `SELECT zone_oid FROM vtec_event_zones WHERE event_key = ? ORDER BY zone_oid`
+	upsertEventZone  = `INSERT INTO vtec_event_zones (event_key, zone_oid, action, updated_at) VALUES (?, ?, ?, ?)
+ON CONFLICT (event_key, zone_oid) DO UPDATE SET action = excluded.action, updated_at = excluded.updated_at`
+	deleteEventZone = `DELETE FROM vtec_event_zones WHERE event_key = ? AND zone_oid = ?`
+	selectEvent     = `SELECT office, phenomena, significance, etn, year, begins, ends, last_action, first_seen, updated_at
+FROM vtec_events WHERE event_key = ?`
+	selectEventsForZone = `SELECT e.event_key FROM vtec_event_zones z INNER JOIN vtec_events e ON e.event_key = z.event_key
+WHERE z.zone_oid = ?1 AND (e.ends IS NULL OR e.ends > ?2)
+ORDER BY e.event_key`
+	deleteEndedEventZones = `DELETE FROM vtec_event_zones
+WHERE event_key IN (SELECT event_key FROM vtec_events WHERE ends IS NOT NULL AND ends <= ?)`
+)
+
+var (
+	// ErrEventNotFound is returned when no event has the requested key
+	ErrEventNotFound = errors.New("event not found")
+)
+
+// Event is a tracked VTEC event
+type Event struct {
+	Key        EventKey  `json:"key"`
+	Begin      time.Time `json:"begin"`
+	End        time.Time `json:"end"`
+	LastAction Action    `json:"lastAction"`
+	FirstSeen  time.Time `json:"firstSeen"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+	// Zones are the OIDs of the zones the event is currently active in
+	Zones []string `json:"zones"`
+}
+
+// Update describes how applying a product changed an event
+type Update struct {
+	Key EventKey `json:"key"`
+	// New is true the first time an event is seen. Anything else is an update to an alert that
+	// has already been posted
+	New bool `json:"new"`
+	// Added are the zones the event became active in
+	Added []string `json:"added,omitempty"`
+	// Removed are the zones the event was cancelled, expired or upgraded in
+	Removed []string `json:"removed,omitempty"`
+	// Active are the zones the event is active in after the update
+	Active []string `json:"active"`
+}
+
+// Tracker records VTEC events and the zones they are active in, in the vtec_events and
+// vtec_event_zones tables of a geodata DB
+type Tracker struct {
+	db *sql.DB
+}
+
+// NewTracker creates a [Tracker] that stores events in the DB of s
+func NewTracker(s *geodata.Store) *Tracker {
+	return &Tracker{db: s.DB()}
+}
+
+// Apply records that a product issued at issued carried v for the zones in zoneOIDs. Actions that
+// end an event (CAN, EXP and UPG) remove it from those zones, and any other action makes it active
+// in them
+func (t *Tracker) Apply(ctx context.Context, v *VTEC, issued time.Time, zoneOIDs []string) (*Update, error) {
+	if ctx == nil {
+		return nil, libwatchedsky.ErrNilContext
+	}
+
+	issued = issued.UTC()
+
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	key, exists, err := eventKey(ctx, tx, v, issued)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &Update{Key: key, New: !exists}
+
+	if _, err = tx.ExecContext(ctx, upsertEvent, key.String(), key.Office, key.Phenomena, key.Significance,
+		key.ETN, key.Year, nullTime(v.Begin), nullTime(v.End), string(v.Action), issued); err != nil {
+		return nil, err
+	}
+
+	before, err := eventZones(ctx, tx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	active := map[string]bool{}
+	for _, oid := range before {
+		active[oid] = true
+	}
+
+	for _, oid := range zoneOIDs {
+		if v.Action.Ends() {
+			if !active[oid] {
+				continue
+			}
+
+			if _, err = tx.ExecContext(ctx, deleteEventZone, key.String(), oid); err != nil {
+				return nil, err
+			}
+
+			delete(active, oid)
+			u.Removed = append(u.Removed, oid)
+			continue
+		}
+
+		if _, err = tx.ExecContext(ctx, upsertEventZone, key.String(), oid, string(v.Action), issued); err != nil {
+			return nil, err
+		}
+
+		if !active[oid] {
+			active[oid] = true
+			u.Added = append(u.Added, oid)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	for oid := range active {
+		u.Active = append(u.Active, oid)
+	}
+	sort.Strings(u.Active)
+	sort.Strings(u.Added)
+	sort.Strings(u.Removed)
+
+	return u, nil
+}
+
+// Event returns the event with the given key, or [ErrEventNotFound]
+func (t *Tracker) Event(ctx context.Context, key EventKey) (*Event, error) {
+	if ctx == nil {
+		return nil, libwatchedsky.ErrNilContext
+	}
+
+	e := &Event{}
+	var (
+		begin, end sql.NullTime
+		action     string
+	)
+
+	err := t.db.QueryRowContext(ctx, selectEvent, key.String()).Scan(&e.Key.Office, &e.Key.Phenomena,
+		&e.Key.Significance, &e.Key.ETN, &e.Key.Year, &begin, &end, &action, &e.FirstSeen, &e.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEventNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	e.Begin, e.End, e.LastAction = begin.Time, end.Time, Action(action)
+
+	if e.Zones, err = eventZones(ctx, t.db, key); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// EventsForZone returns the keys of the events active in a zone at time at
+func (t *Tracker) EventsForZone(ctx context.Context, oid string, at time.Time) ([]string, error) {
+	if ctx == nil {
+		return nil, libwatchedsky.ErrNilContext
+	}
+
+	rows, err := t.db.QueryContext(ctx, selectEventsForZone, oid, at.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err = rows.Scan(&key); err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// ExpireEnded clears the zones of every event that ended at or before at, for events whose
+// expiration was never sent. The events themselves are kept so a late product is not mistaken
+// for a new event
+func (t *Tracker) ExpireEnded(ctx context.Context, at time.Time) (int64, error) {
+	if ctx == nil {
+		return 0, libwatchedsky.ErrNilContext
+	}
+
+	res, err := t.db.ExecContext(ctx, deleteEndedEventZones, at.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// eventKey returns the key of the event v refers to and whether it has been seen. Products for an
+// event that has already begun carry no begin time, so [VTEC.Key] takes the year from issued, and
+// one issued early in January may continue an event from December. If no event this year has the
+// key, an unexpired event from the year before is used instead
+func eventKey(ctx context.Context, q geodata.Querier, v *VTEC, issued time.Time) (EventKey, bool, error) {
+	key := v.Key(issued)
+
+	var exists bool
+	if err := q.QueryRowContext(ctx, selectEventExists, key.String()).Scan(&exists); err != nil {
+		return EventKey{}, false, err
+	}
+
+	if exists || !v.Begin.IsZero() || v.Action == ActionNew {
+		return key, exists, nil
+	}
+
+	prev := key
+	prev.Year--
+
+	if err := q.QueryRowContext(ctx, selectEventUnexpired, prev.String(), issued).Scan(&exists); err != nil {
+		return EventKey{}, false, err
+	}
+
+	if exists {
+		return prev, true, nil
+	}
+
+	return key, false, nil
+}
+
+func eventZones(ctx context.Context, q geodata.Querier, key EventKey) ([]string, error) {
+	rows, err := q.QueryContext(ctx, selectEventZones, key.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var oids []string
+	for rows.Next() {
+		var oid string
+		if err = rows.Scan(&oid); err != nil {
+			return nil, err
+		}
+
+		oids = append(oids, oid)
+	}
+
+	return oids, rows.Err()
+}
+
+func nullTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+
+	return t.UTC()
+}
@@ -0,0 +1,219 @@
+package vtec
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/watchedsky-social/libwatchedsky"
+	"github.com/watchedsky-social/libwatchedsky/geodata"
+
+	// registers the plain sqlite3 driver as well as spatialite, which tracking events does not need
+	_ "github.com/watchedsky-social/go-spatialite"
+)
+
+// newTestTracker returns a Tracker over a DB with the VTEC tables and a few zones
+func newTestTracker(t *testing.T) *Tracker {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	for _, stmt := range []string{
+		`CREATE TABLE zones (oid TEXT NOT NULL PRIMARY KEY)`,
+		`INSERT INTO zones (oid) VALUES ('county-35'), ('county-49'), ('county-93')`,
+		`CREATE TABLE vtec_events (event_key TEXT NOT NULL PRIMARY KEY, office TEXT NOT NULL,
+  phenomena TEXT NOT NULL, significance TEXT NOT NULL, etn INTEGER NOT NULL, year INTEGER NOT NULL,
+  begins TIMESTAMP DEFAULT NULL, ends TIMESTAMP DEFAULT NULL, last_action TEXT NOT NULL,
+  first_seen TIMESTAMP NOT NULL, updated_at TIMESTAMP NOT NULL)`,
+		`CREATE TABLE vtec_event_zones (event_key TEXT NOT NULL, zone_oid TEXT NOT NULL, action TEXT NOT NULL,
+  updated_at TIMESTAMP NOT NULL, PRIMARY KEY (event_key, zone_oid),
+  FOREIGN KEY (event_key) REFERENCES vtec_events (event_key) ON DELETE CASCADE,
+  FOREIGN KEY (zone_oid) REFERENCES zones (oid) ON DELETE CASCADE)`,
+	} {
+		if _, err = db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return NewTracker(geodata.NewStore(db))
+}
+
+func mustParse(t *testing.T, s string) *VTEC {
+	t.Helper()
+
+	v, err := Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return v
+}
+
+func TestTrackerApply(t *testing.T) {
+	tr := newTestTracker(t)
+	ctx := context.Background()
+	issued := time.Date(2025, time.October, 17, 21, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		name    string
+		vtec    string
+		zones   []string
+		new     bool
+		added   []string
+		removed []string
+		active  []string
+	}{
+		{
+			name:   "new",
+			vtec:   "/O.NEW.KCLE.SV.W.0101.251017T2100Z-251017T2200Z/",
+			zones:  []string{"county-49", "county-35"},
+			new:    true,
+			added:  []string{"county-35", "county-49"},
+			active: []string{"county-35", "county-49"},
+		},
+		{
+			name:   "continued and extended into another zone",
+			vtec:   "/O.EXA.KCLE.SV.W.0101.000000T0000Z-251017T2200Z/",
+			zones:  []string{"county-93"},
+			added:  []string{"county-93"},
+			active: []string{"county-35", "county-49", "county-93"},
+		},
+		{
+			name:   "continued where already active",
+			vtec:   "/O.CON.KCLE.SV.W.0101.000000T0000Z-251017T2200Z/",
+			zones:  []string{"county-35", "county-49"},
+			active: []string{"county-35", "county-49", "county-93"},
+		},
+		{
+			name:    "cancelled in part",
+			vtec:    "/O.CAN.KCLE.SV.W.0101.000000T0000Z-251017T2200Z/",
+			zones:   []string{"county-49", "county-93"},
+			removed: []string{"county-49", "county-93"},
+			active:  []string{"county-35"},
+		},
+		{
+			name:   "cancelled where not active",
+			vtec:   "/O.CAN.KCLE.SV.W.0101.000000T0000Z-251017T2200Z/",
+			zones:  []string{"county-49"},
+			active: []string{"county-35"},
+		},
+	}
+
+	for i, step := range steps {
+		u, err := tr.Apply(ctx, mustParse(t, step.vtec), issued.Add(time.Duration(i)*10*time.Minute), step.zones)
+		if err != nil {
+			t.Fatalf("%s: Apply() = %v", step.name, err)
+		}
+
+		if u.Key.String() != "KCLE.SV.W.0101.2025" || u.New != step.new {
+			t.Errorf("%s: key, new = %s, %t, want KCLE.SV.W.0101.2025, %t", step.name, u.Key, u.New, step.new)
+		}
+
+		if !slices.Equal(u.Added, step.added) || !slices.Equal(u.Removed, step.removed) ||
+			!slices.Equal(u.Active, step.active) {
+			t.Errorf("%s: added, removed, active = %v, %v, %v, want %v, %v, %v", step.name,
+				u.Added, u.Removed, u.Active, step.added, step.removed, step.active)
+		}
+	}
+
+	e, err := tr.Event(ctx, EventKey{Office: "KCLE", Phenomena: "SV", Significance: "W", ETN: 101, Year: 2025})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !e.Begin.Equal(issued) || !e.End.Equal(issued.Add(time.Hour)) || e.LastAction != ActionCancel ||
+		!e.FirstSeen.Equal(issued) || !slices.Equal(e.Zones, []string{"county-35"}) {
+		t.Errorf("Event() = %+v, want the begin time kept from the NEW product", e)
+	}
+
+	if _, err = tr.Event(ctx, EventKey{Office: "KCLE", Phenomena: "SV", Significance: "W", ETN: 102, Year: 2025}); !errors.Is(err, ErrEventNotFound) {
+		t.Errorf("Event() for an unknown key = %v, want %v", err, ErrEventNotFound)
+	}
+}
+
+func TestTrackerExpireEnded(t *testing.T) {
+	tr := newTestTracker(t)
+	ctx := context.Background()
+	issued := time.Date(2025, time.October, 17, 21, 0, 0, 0, time.UTC)
+
+	for _, s := range []string{
+		"/O.NEW.KCLE.SV.W.0101.251017T2100Z-251017T2200Z/",
+		"/O.NEW.KCLE.TO.W.0012.251017T2100Z-251017T2300Z/",
+	} {
+		if _, err := tr.Apply(ctx, mustParse(t, s), issued, []string{"county-35"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	at := issued.Add(90 * time.Minute)
+	if keys, err := tr.EventsForZone(ctx, "county-35", at); err != nil ||
+		!slices.Equal(keys, []string{"KCLE.TO.W.0012.2025"}) {
+		t.Errorf("EventsForZone() = %v, %v, want only the unexpired tornado warning", keys, err)
+	}
+
+	n, err := tr.ExpireEnded(ctx, at)
+	if err != nil || n != 1 {
+		t.Fatalf("ExpireEnded() = %d, %v, want 1", n, err)
+	}
+
+	// the expired event is kept, without zones, so a late product still finds it
+	e, err := tr.Event(ctx, EventKey{Office: "KCLE", Phenomena: "SV", Significance: "W", ETN: 101, Year: 2025})
+	if err != nil || len(e.Zones) != 0 {
+		t.Errorf("Event() = %+v, %v, want the event with no zones", e, err)
+	}
+
+	if n, err = tr.ExpireEnded(ctx, at); err != nil || n != 0 {
+		t.Errorf("ExpireEnded() again = %d, %v, want 0", n, err)
+	}
+
+	if _, err = tr.ExpireEnded(nil, at); !errors.Is(err, libwatchedsky.ErrNilContext) {
+		t.Errorf("ExpireEnded(nil) = %v, want %v", err, libwatchedsky.ErrNilContext)
+	}
+}
+
+func TestTrackerCarriesOverYear(t *testing.T) {
+	tr := newTestTracker(t)
+	ctx := context.Background()
+	december := time.Date(2025, time.December, 31, 22, 0, 0, 0, time.UTC)
+	january := time.Date(2026, time.January, 1, 1, 0, 0, 0, time.UTC)
+
+	if _, err := tr.Apply(ctx, mustParse(t, "/O.NEW.KCLE.WS.W.0040.251231T2200Z-260101T1200Z/"), december,
+		[]string{"county-35"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the event has begun, so the continuation has no begin time and is issued in the new year
+	u, err := tr.Apply(ctx, mustParse(t, "/O.CON.KCLE.WS.W.0040.000000T0000Z-260101T1200Z/"), january,
+		[]string{"county-35", "county-49"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Key.String() != "KCLE.WS.W.0040.2025" || u.New || !slices.Equal(u.Added, []string{"county-49"}) {
+		t.Errorf("Apply() = %+v, want the December event extended", u)
+	}
+
+	// once the December event has ended, the same ETN in January is a new event
+	if _, err = tr.Apply(ctx, mustParse(t, "/O.CAN.KCLE.WS.W.0040.000000T0000Z-260101T1200Z/"), january,
+		[]string{"county-35", "county-49"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if u, err = tr.Apply(ctx, mustParse(t, "/O.CON.KCLE.WS.W.0040.000000T0000Z-260101T1200Z/"), january,
+		[]string{"county-35"}); err != nil || u.Key.Year != 2026 || !u.New {
+		t.Errorf("Apply() after cancelling = %+v, %v, want a new 2026 event", u, err)
+	}
+
+	if _, err = tr.Apply(nil, mustParse(t, "/O.CON.KCLE.WS.W.0040.000000T0000Z-260101T1200Z/"), january,
+		nil); !errors.Is(err, libwatchedsky.ErrNilContext) {
+		t.Errorf("Apply(nil) = %v, want %v", err, libwatchedsky.ErrNilContext)
+	}
+}
@@ -0,0 +1,169 @@
+// Package vtec parses the Primary Valid Time Event Code (P-VTEC) strings carried by NWS products,
+// such as
+//
+//	/O.NEW.KCLE.TO.W.0012.251017T2100Z-251017T2145Z/
+//
+// and tracks the zones each event is active in
+package vtec
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Action is what a product does to an event
+type Action string
+
+// The P-VTEC actions
+const (
+	ActionNew        Action = "NEW"
+	ActionContinue   Action = "CON"
+	ActionExtendTime Action = "EXT"
+	ActionExtendArea Action = "EXA"
+	ActionExtendBoth Action = "EXB"
+	ActionUpgrade    Action = "UPG"
+	ActionCancel     Action = "CAN"
+	ActionExpire     Action = "EXP"
+	ActionCorrect    Action = "COR"
+	ActionRoutine    Action = "ROU"
+)
+
+// Ends reports whether the action ends the event in the zones it is issued for
+func (a Action) Ends() bool {
+	return a == ActionCancel || a == ActionExpire || a == ActionUpgrade
+}
+
+var (
+	// ErrInvalidVTEC is returned when a string is not a P-VTEC string
+	ErrInvalidVTEC = errors.New("invalid VTEC")
+
+	pvtecPattern = regexp.MustCompile(`/([OTEX])\.(NEW|CON|EXT|EXA|EXB|UPG|CAN|EXP|COR|ROU)\.([A-Z0-9]{4})\.([A-Z]{2})\.([A-Z])\.(\d{4})\.(\d{6}T\d{4}Z)-(\d{6}T\d{4}Z)/`)
+)
+
+// timeLayout is the layout of the begin and end times. 000000T0000Z means the time is not given
+const (
+	timeLayout  = "060102T1504Z"
+	noTimeValue = "000000T0000Z"
+)
+
+// VTEC is a parsed P-VTEC string
+type VTEC struct {
+	// Class is O for operational products, or T, E or X for test, experimental and experimental
+	// VTEC in operational products
+	Class        string
+	Action       Action
+	Office       string
+	Phenomena    string
+	Significance string
+	// ETN is the event tracking number, which is unique per office, phenomena and significance
+	// within a year
+	ETN   int
+	Begin time.Time
+	End   time.Time
+}
+
+// Parse parses a single P-VTEC string
+func Parse(s string) (*VTEC, error) {
+	m := pvtecPattern.FindStringSubmatch(s)
+	if m == nil || len(m[0]) != len(s) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidVTEC, s)
+	}
+
+	return fromMatch(m)
+}
+
+// ParseAll returns every P-VTEC string in the text of a product
+func ParseAll(text string) ([]*VTEC, error) {
+	var all []*VTEC
+	for _, m := range pvtecPattern.FindAllStringSubmatch(text, -1) {
+		v, err := fromMatch(m)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, v)
+	}
+
+	return all, nil
+}
+
+func fromMatch(m []string) (*VTEC, error) {
+	etn, _ := strconv.Atoi(m[6])
+
+	v := &VTEC{
+		Class:        m[1],
+		Action:       Action(m[2]),
+		Office:       m[3],
+		Phenomena:    m[4],
+		Significance: m[5],
+		ETN:          etn,
+	}
+
+	var err error
+	if v.Begin, err = parseTime(m[7]); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidVTEC, m[0], err)
+	}
+
+	if v.End, err = parseTime(m[8]); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidVTEC, m[0], err)
+	}
+
+	return v, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == noTimeValue {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(timeLayout, s)
+}
+
+// String returns the P-VTEC string
+func (v *VTEC) String() string {
+	return fmt.Sprintf("/%s.%s.%s.%s.%s.%04d.%s-%s/", v.Class, v.Action, v.Office, v.Phenomena,
+		v.Significance, v.ETN, formatTime(v.Begin), formatTime(v.End))
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return noTimeValue
+	}
+
+	return t.UTC().Format(timeLayout)
+}
+
+// EventKey identifies an event across every product that refers to it
+type EventKey struct {
+	Office       string
+	Phenomena    string
+	Significance string
+	ETN          int
+	Year         int
+}
+
+// String returns the key in the form KCLE.TO.W.0012.2025
+func (k EventKey) String() string {
+	return fmt.Sprintf("%s.%s.%s.%04d.%d", k.Office, k.Phenomena, k.Significance, k.ETN, k.Year)
+}
+
+// Key returns the key of the event. ETNs restart every year, so the year is taken from the begin
+// time, or from issued for products where the event has already begun. [Tracker.Apply] carries
+// such products over to an unexpired event from the year before
+func (v *VTEC) Key(issued time.Time) EventKey {
+	year := issued.UTC().Year()
+	if !v.Begin.IsZero() {
+		year = v.Begin.Year()
+	}
+
+	return EventKey{
+		Office:       v.Office,
+		Phenomena:    v.Phenomena,
+		Significance: v.Significance,
+		ETN:          v.ETN,
+		Year:         year,
+	}
+}
@@ -0,0 +1,159 @@
+package vtec
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want VTEC
+	}{
+		{
+			name: "new warning",
+			s:    "/O.NEW.KCLE.TO.W.0012.251017T2100Z-251017T2145Z/",
+			want: VTEC{
+				Class: "O", Action: ActionNew, Office: "KCLE", Phenomena: "TO", Significance: "W", ETN: 12,
+				Begin: time.Date(2025, time.October, 17, 21, 0, 0, 0, time.UTC),
+				End:   time.Date(2025, time.October, 17, 21, 45, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "event already begun",
+			s:    "/O.CON.KILN.FW.W.0003.000000T0000Z-251019T0000Z/",
+			want: VTEC{
+				Class: "O", Action: ActionContinue, Office: "KILN", Phenomena: "FW", Significance: "W", ETN: 3,
+				End: time.Date(2025, time.October, 19, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "no end time",
+			s:    "/T.EXT.PAFG.FL.W.0001.251017T2100Z-000000T0000Z/",
+			want: VTEC{
+				Class: "T", Action: ActionExtendTime, Office: "PAFG", Phenomena: "FL", Significance: "W", ETN: 1,
+				Begin: time.Date(2025, time.October, 17, 21, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Parse(tt.s)
+			if err != nil {
+				t.Fatalf("Parse(%q) = %v", tt.s, err)
+			}
+
+			if *v != tt.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.s, *v, tt.want)
+			}
+
+			if got := v.String(); got != tt.s {
+				t.Errorf("String() = %q, want %q", got, tt.s)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+	}{
+		{name: "empty", s: ""},
+		{name: "trailing suffix", s: "/O.NEW.KCLE.TO.W.0012.251017T2100Z-251017T2145Z/extra"},
+		{name: "leading prefix", s: "WWUS51 /O.NEW.KCLE.TO.W.0012.251017T2100Z-251017T2145Z/"},
+		{name: "unknown action", s: "/O.FOO.KCLE.TO.W.0012.251017T2100Z-251017T2145Z/"},
+		{name: "short ETN", s: "/O.NEW.KCLE.TO.W.012.251017T2100Z-251017T2145Z/"},
+		{name: "no end", s: "/O.NEW.KCLE.TO.W.0012.251017T2100Z/"},
+		{name: "bad month", s: "/O.NEW.KCLE.TO.W.0012.251317T2100Z-251017T2145Z/"},
+		{name: "H-VTEC", s: "/00000.0.ER.000000T0000Z.000000T0000Z.000000T0000Z.OO/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if v, err := Parse(tt.s); !errors.Is(err, ErrInvalidVTEC) {
+				t.Errorf("Parse(%q) = %+v, %v, want %v", tt.s, v, err, ErrInvalidVTEC)
+			}
+		})
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	const product = `WWUS81 KCLE 172100
+SVSCLE
+
+OHC035-049-172145-
+/O.CON.KCLE.TO.W.0012.000000T0000Z-251017T2145Z/
+/O.NEW.KCLE.SV.W.0101.251017T2100Z-251017T2200Z/
+/00000.0.ER.000000T0000Z.000000T0000Z.000000T0000Z.OO/
+`
+
+	all, err := ParseAll(product)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(all) != 2 || all[0].Phenomena != "TO" || all[1].Phenomena != "SV" {
+		t.Errorf("ParseAll() = %+v, want the tornado and severe thunderstorm warnings", all)
+	}
+
+	if all, err = ParseAll("no VTEC here"); err != nil || len(all) != 0 {
+		t.Errorf("ParseAll() = %+v, %v, want nothing", all, err)
+	}
+
+	if _, err = ParseAll("/O.NEW.KCLE.TO.W.0012.251399T2100Z-251017T2145Z/"); !errors.Is(err, ErrInvalidVTEC) {
+		t.Errorf("ParseAll() with a bad time = %v, want %v", err, ErrInvalidVTEC)
+	}
+}
+
+func TestKey(t *testing.T) {
+	issued := time.Date(2026, time.January, 1, 3, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{
+			name: "year of the begin time",
+			s:    "/O.NEW.KCLE.WS.W.0002.251231T2300Z-260101T1200Z/",
+			want: "KCLE.WS.W.0002.2025",
+		},
+		{
+			name: "year issued, in UTC, when the event has begun",
+			s:    "/O.CON.KCLE.WS.W.0002.000000T0000Z-260101T1200Z/",
+			want: "KCLE.WS.W.0002.2026",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Parse(tt.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := v.Key(issued).String(); got != tt.want {
+				t.Errorf("Key() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActionEnds(t *testing.T) {
+	for action, want := range map[Action]bool{
+		ActionNew:        false,
+		ActionContinue:   false,
+		ActionExtendArea: false,
+		ActionCancel:     true,
+		ActionExpire:     true,
+		ActionUpgrade:    true,
+	} {
+		if got := action.Ends(); got != want {
+			t.Errorf("%s.Ends() = %t, want %t", action, got, want)
+		}
+	}
+}